@@ -1,10 +1,12 @@
 package auth
 
 import (
+	"crypto/rsa"
 	"errors"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/golang-jwt/jwt/v4"
 )
@@ -33,6 +35,12 @@ const (
 	ServiceContextAPI = "servicecontextapi"
 )
 
+// _legacyHeaders are the headers set by SetLegacyHeaders.
+var _legacyHeaders = []string{BusinessUnits, Owner, Role}
+
+// _tokenHeader is the header Pomelo tokens are sent in.
+const _tokenHeader = "X-Auth-Token"
+
 // Claims the JWT Claims Set represents a JSON object whose members are the claims conveyed by the JWT.
 //
 // RFC for more info https://datatracker.ietf.org/doc/html/rfc7519#section-4
@@ -49,26 +57,75 @@ type Claims struct {
 }
 
 // DecodeToken provides a logic to decode Pomelo token.
+//
+// The token is verified with the RSA public key set in the
+// CONTEXT_API_PUBLIC_KEY environment variable, see Verifier to verify tokens
+// against several keys or a JWKS document.
+//
+// DecodeToken does not modify the request, see Middleware to expose the
+// Identity of the token to handlers and SetLegacyHeaders for services that
+// still read the claims from the request headers.
 func DecodeToken(r *http.Request) (*Claims, error) {
-	tokenHeader := r.Header.Get("X-Auth-Token")
+	return claimsOf(decodeEnvToken(r))
+}
+
+func decodeEnvToken(r *http.Request) (*jwt.Token, error) {
+	return decodeToken(r, ensureValidToken)
+}
+
+func decodeToken(r *http.Request, parse func(tokenHeader string) (*jwt.Token, error)) (*jwt.Token, error) {
+	tokenHeader := r.Header.Get(_tokenHeader)
 	if tokenHeader == "" {
 		return nil, ErrRequestNotAcceptable
 	}
 
-	token, err := ensureValidToken(tokenHeader)
+	token, err := parse(tokenHeader)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := checkPomeloClaims(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// checkPomeloClaims checks the claims every Pomelo token must carry.
+func checkPomeloClaims(token *jwt.Token) error {
 	claims, ok := token.Claims.(*Claims)
 	if !ok {
-		return nil, ErrTypeAssertionsClaims
+		return ErrTypeAssertionsClaims
 	}
 	if len(claims.Area) == 0 {
-		return nil, ErrMissingAreas
+		return ErrMissingAreas
 	}
 	if claims.Email == "" && !claims.VerifyAudience(ServiceContextAPI, true) {
-		return nil, ErrMissingEmail
+		return ErrMissingEmail
+	}
+
+	return nil
+}
+
+// claimsOf returns the claims of a token checked by checkPomeloClaims.
+func claimsOf(token *jwt.Token, err error) (*Claims, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	return token.Claims.(*Claims), nil
+}
+
+// SetLegacyHeaders sets the claims in the business-units, owner and role
+// headers of the request, as DecodeToken used to do. It is meant as a
+// compatibility mode for services that still read those headers; new code
+// should use IdentityFromContext instead.
+//
+// The values sent by the client in those headers are removed first, so that
+// they cannot be spoofed with claims the token does not carry.
+func SetLegacyHeaders(r *http.Request, claims *Claims) {
+	for _, header := range _legacyHeaders {
+		r.Header.Del(header)
 	}
 
 	r.Header.Set(BusinessUnits, strings.Join(claims.Area, ","))
@@ -82,15 +139,51 @@ func DecodeToken(r *http.Request) (*Claims, error) {
 		r.Header.Set(Role, "service_"+serviceName)
 		r.Header.Set(Owner, serviceName)
 	}
+}
 
-	return claims, nil
+// envPublicKey caches the public key parsed from the CONTEXT_API_PUBLIC_KEY
+// environment variable, parsing it again only when the variable changes.
+type envPublicKey struct {
+	mu  sync.Mutex
+	pem string
+	key *rsa.PublicKey
+	err error
 }
 
+var _envPublicKey envPublicKey
+
 //revive:disable:unused-parameter
+func (k *envPublicKey) keyfunc(token *jwt.Token) (any, error) {
+	pemKey := os.Getenv("CONTEXT_API_PUBLIC_KEY")
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if (k.key == nil && k.err == nil) || k.pem != pemKey {
+		k.pem = pemKey
+		k.key, k.err = jwt.ParseRSAPublicKeyFromPEM([]byte(pemKey))
+	}
+
+	return k.key, k.err
+}
+
+//revive:enable:unused-parameter
+
 func ensureValidToken(tokenHeader string) (*jwt.Token, error) {
-	token, err := jwt.ParseWithClaims(tokenHeader, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwt.ParseRSAPublicKeyFromPEM([]byte(os.Getenv("CONTEXT_API_PUBLIC_KEY")))
-	})
+	return validToken(tokenHeader, _envPublicKey.keyfunc)
+}
+
+// verify parses and verifies tokenString with the given parser. Errors are
+// returned as reported by the parser.
+func (k *envPublicKey) verify(parser *jwt.Parser, tokenString string) (*jwt.Token, error) {
+	return parser.ParseWithClaims(tokenString, &Claims{}, k.keyfunc)
+}
+
+func validToken(tokenHeader string, keyfunc jwt.Keyfunc) (*jwt.Token, error) {
+	return checkToken(jwt.ParseWithClaims(tokenHeader, &Claims{}, keyfunc))
+}
+
+func checkToken(token *jwt.Token, err error) (*jwt.Token, error) {
 	switch {
 	case errors.Is(err, jwt.ErrSignatureInvalid):
 		return nil, ErrRequestNotAcceptable
@@ -104,5 +197,3 @@ func ensureValidToken(tokenHeader string) (*jwt.Token, error) {
 
 	return token, nil
 }
-
-//revive:enable:unused-parameter
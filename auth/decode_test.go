@@ -144,6 +144,12 @@ func TestDecodeUserToken(t *testing.T) {
 			// When
 			got, err := auth.DecodeToken(req)
 
+			// Then the request headers are only set in compatibility mode
+			assert.Empty(t, req.Header.Get(auth.BusinessUnits))
+			if err == nil {
+				auth.SetLegacyHeaders(req, got)
+			}
+
 			// Then
 			if tt.want.err != nil {
 				assert.ErrorIs(t, tt.want.err, err)
@@ -211,6 +217,12 @@ func TestDecodeServiceToken(t *testing.T) {
 			// When
 			got, err := auth.DecodeToken(req)
 
+			// Then the request headers are only set in compatibility mode
+			assert.Empty(t, req.Header.Get(auth.BusinessUnits))
+			if err == nil {
+				auth.SetLegacyHeaders(req, got)
+			}
+
 			// Then
 			if tt.want.err != nil {
 				assert.ErrorIs(t, tt.want.err, err)
@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	// ErrTokenExpired indicates that the token is expired. It wraps ErrUnauthorized.
+	ErrTokenExpired = fmt.Errorf("%w: token is expired", ErrUnauthorized)
+	// ErrTokenNotValidYet indicates that the token is not valid yet, either by
+	// its nbf or iat claim. It wraps ErrUnauthorized.
+	ErrTokenNotValidYet = fmt.Errorf("%w: token is not valid yet", ErrUnauthorized)
+	// ErrInvalidSignature indicates that the signature of the token does not
+	// match any trusted key. It wraps ErrRequestNotAcceptable.
+	ErrInvalidSignature = fmt.Errorf("%w: token signature is invalid", ErrRequestNotAcceptable)
+	// ErrAlgorithmNotAllowed indicates that the token is signed with an
+	// algorithm that is not allowed. It wraps ErrUnauthorized.
+	ErrAlgorithmNotAllowed = fmt.Errorf("%w: token signing algorithm is not allowed", ErrUnauthorized)
+	// ErrInvalidIssuer indicates that the token was issued by an untrusted
+	// issuer. It wraps ErrUnauthorized.
+	ErrInvalidIssuer = fmt.Errorf("%w: token issuer is not allowed", ErrUnauthorized)
+	// ErrInvalidAudience indicates that the token is not meant for any of the
+	// expected audiences. It wraps ErrUnauthorized.
+	ErrInvalidAudience = fmt.Errorf("%w: token audience is not allowed", ErrUnauthorized)
+	// ErrMissingClaim indicates that a required claim is missing or empty. It
+	// wraps ErrUnauthorized.
+	ErrMissingClaim = fmt.Errorf("%w: token is missing a required claim", ErrUnauthorized)
+)
+
+// TokenSource extracts the raw token from a request. It returns an empty
+// string if the request does not carry a token.
+type TokenSource func(r *http.Request) string
+
+// FromHeader reads the token from the given header.
+func FromHeader(name string) TokenSource {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// FromBearer reads the token from the Authorization header using the Bearer scheme.
+func FromBearer(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// FromCookie reads the token from the cookie with the given name.
+func FromCookie(name string) TokenSource {
+	return func(r *http.Request) string {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return ""
+		}
+
+		return cookie.Value
+	}
+}
+
+// keySource verifies the signature of tokens.
+type keySource interface {
+	verify(parser *jwt.Parser, tokenString string) (*jwt.Token, error)
+}
+
+// DecoderOptions represents the options for configuring a Decoder.
+type DecoderOptions struct {
+	TokenSources   []TokenSource
+	Verifier       *Verifier
+	Issuers        []string
+	Audiences      []string
+	Leeway         time.Duration
+	Algorithms     []string
+	RequiredClaims []string
+	LegacyHeaders  bool
+}
+
+// WithTokenSources allows you to configure where the token is read from. The
+// sources are tried in order and the first token found is used.
+//
+// Default behavior is to read the token from the X-Auth-Token header.
+func WithTokenSources(sources ...TokenSource) func(opts *DecoderOptions) {
+	return func(opts *DecoderOptions) {
+		opts.TokenSources = sources
+	}
+}
+
+// WithVerifier allows you to configure the keys used to verify the signature
+// of the tokens.
+//
+// Default behavior is to use the RSA public key set in the
+// CONTEXT_API_PUBLIC_KEY environment variable.
+func WithVerifier(v *Verifier) func(opts *DecoderOptions) {
+	return func(opts *DecoderOptions) {
+		opts.Verifier = v
+	}
+}
+
+// WithIssuers allows you to configure the trusted issuers. Tokens whose iss
+// claim is not any of them are rejected with ErrInvalidIssuer.
+func WithIssuers(issuers ...string) func(opts *DecoderOptions) {
+	return func(opts *DecoderOptions) {
+		opts.Issuers = issuers
+	}
+}
+
+// WithAudiences allows you to configure the expected audiences. Tokens whose
+// aud claim does not contain any of them are rejected with ErrInvalidAudience.
+func WithAudiences(audiences ...string) func(opts *DecoderOptions) {
+	return func(opts *DecoderOptions) {
+		opts.Audiences = audiences
+	}
+}
+
+// WithLeeway allows you to configure the clock skew tolerated when checking
+// the exp, nbf and iat claims.
+func WithLeeway(leeway time.Duration) func(opts *DecoderOptions) {
+	return func(opts *DecoderOptions) {
+		opts.Leeway = leeway
+	}
+}
+
+// WithAlgorithms allows you to configure the signing algorithms accepted.
+// Tokens signed with any other algorithm are rejected with ErrAlgorithmNotAllowed.
+//
+// Default behavior is to accept RS256, ES256 and EdDSA.
+func WithAlgorithms(algorithms ...string) func(opts *DecoderOptions) {
+	return func(opts *DecoderOptions) {
+		opts.Algorithms = algorithms
+	}
+}
+
+// WithRequiredClaims allows you to configure the claims, registered or custom,
+// every token must carry with a non-empty value. Tokens missing any of them
+// are rejected with ErrMissingClaim.
+func WithRequiredClaims(claims ...string) func(opts *DecoderOptions) {
+	return func(opts *DecoderOptions) {
+		opts.RequiredClaims = claims
+	}
+}
+
+// WithLegacyHeaders enables the compatibility mode in which the claims are
+// also set in the business-units, owner and role headers of the request, see
+// SetLegacyHeaders.
+func WithLegacyHeaders() func(opts *DecoderOptions) {
+	return func(opts *DecoderOptions) {
+		opts.LegacyHeaders = true
+	}
+}
+
+// Decoder decodes Pomelo tokens like DecodeToken, with configurable token
+// sources, keys and claim validation.
+type Decoder struct {
+	opts   DecoderOptions
+	keys   keySource
+	parser *jwt.Parser
+}
+
+// NewDecoder instantiates a Decoder.
+func NewDecoder(optFns ...func(opts *DecoderOptions)) *Decoder {
+	opts := DecoderOptions{
+		TokenSources: []TokenSource{FromHeader(_tokenHeader)},
+		Algorithms: []string{
+			jwt.SigningMethodRS256.Alg(),
+			jwt.SigningMethodES256.Alg(),
+			jwt.SigningMethodEdDSA.Alg(),
+		},
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	var keys keySource = &_envPublicKey
+	if opts.Verifier != nil {
+		keys = opts.Verifier
+	}
+
+	return &Decoder{
+		opts: opts,
+		keys: keys,
+		// Registered claims are checked by the Decoder, honoring the leeway.
+		parser: jwt.NewParser(jwt.WithValidMethods(opts.Algorithms), jwt.WithoutClaimsValidation()),
+	}
+}
+
+// DecodeToken decodes and validates the token of the request. Requests without
+// a token are rejected with ErrRequestNotAcceptable.
+func (d *Decoder) DecodeToken(r *http.Request) (*Claims, error) {
+	claims, err := claimsOf(d.decode(r))
+	if err != nil {
+		return nil, err
+	}
+
+	if d.opts.LegacyHeaders {
+		SetLegacyHeaders(r, claims)
+	}
+
+	return claims, nil
+}
+
+// Middleware behaves like the package-level Middleware, but decodes tokens
+// with the sources, algorithms and leeway of the Decoder.
+func (d *Decoder) Middleware(next http.Handler) http.Handler {
+	return decodeMiddleware(d.decode, d.opts.LegacyHeaders)(next)
+}
+
+func (d *Decoder) decode(r *http.Request) (*jwt.Token, error) {
+	tokenString := d.tokenString(r)
+	if tokenString == "" {
+		return nil, ErrRequestNotAcceptable
+	}
+
+	token, err := d.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPomeloClaims(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (d *Decoder) tokenString(r *http.Request) string {
+	for _, source := range d.opts.TokenSources {
+		if token := source(r); token != "" {
+			return token
+		}
+	}
+
+	return ""
+}
+
+func (d *Decoder) parse(tokenString string) (*jwt.Token, error) {
+	unverified, _, err := d.parser.ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return nil, ErrUnauthorized
+	}
+	if !slices.Contains(d.opts.Algorithms, unverified.Method.Alg()) {
+		return nil, ErrAlgorithmNotAllowed
+	}
+
+	token, err := d.keys.verify(d.parser, tokenString)
+	switch {
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		return nil, ErrInvalidSignature
+	case err != nil:
+		return nil, ErrUnauthorized
+	}
+
+	if err := d.validate(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (d *Decoder) validate(token *jwt.Token) error {
+	claims, ok := token.Claims.(*Claims)
+	if !ok {
+		return ErrTypeAssertionsClaims
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt != nil && now.After(claims.ExpiresAt.Add(d.opts.Leeway)) {
+		return ErrTokenExpired
+	}
+	if claims.NotBefore != nil && now.Add(d.opts.Leeway).Before(claims.NotBefore.Time) {
+		return ErrTokenNotValidYet
+	}
+	if claims.IssuedAt != nil && now.Add(d.opts.Leeway).Before(claims.IssuedAt.Time) {
+		return ErrTokenNotValidYet
+	}
+
+	if len(d.opts.Issuers) > 0 && !slices.Contains(d.opts.Issuers, claims.Issuer) {
+		return ErrInvalidIssuer
+	}
+	if len(d.opts.Audiences) > 0 && !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(d.opts.Audiences, aud)
+	}) {
+		return ErrInvalidAudience
+	}
+
+	return d.requireClaims(token.Raw)
+}
+
+func (d *Decoder) requireClaims(tokenString string) error {
+	if len(d.opts.RequiredClaims) == 0 {
+		return nil
+	}
+
+	raw := jwt.MapClaims{}
+	if _, _, err := d.parser.ParseUnverified(tokenString, raw); err != nil {
+		return ErrUnauthorized
+	}
+
+	for _, name := range d.opts.RequiredClaims {
+		if isEmptyClaim(raw[name]) {
+			return fmt.Errorf("%w: %s", ErrMissingClaim, name)
+		}
+	}
+
+	return nil
+}
+
+func isEmptyClaim(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
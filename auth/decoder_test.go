@@ -0,0 +1,329 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/auth"
+)
+
+func encodePublicKeyPEM(t *testing.T, key crypto.PublicKey) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// customClaims are Pomelo claims along with a custom one.
+type customClaims struct {
+	auth.Claims
+	Tenant string `json:"tenant,omitempty"`
+}
+
+func signClaims(t *testing.T, method jwt.SigningMethod, key crypto.PrivateKey, claims jwt.Claims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestDecoderTokenSources(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	token := signToken(t, verifierClaims)
+	decoder := auth.NewDecoder(auth.WithTokenSources(
+		auth.FromBearer,
+		auth.FromCookie("session"),
+		auth.FromHeader("X-Token"),
+	))
+
+	tests := []struct {
+		name    string
+		prepare func(r *http.Request)
+		err     error
+	}{
+		{
+			name: "bearer",
+			prepare: func(r *http.Request) {
+				r.Header.Set("Authorization", "Bearer "+token)
+			},
+		},
+		{
+			name: "bearer lowercase scheme",
+			prepare: func(r *http.Request) {
+				r.Header.Set("Authorization", "bearer "+token)
+			},
+		},
+		{
+			name: "cookie",
+			prepare: func(r *http.Request) {
+				r.AddCookie(&http.Cookie{Name: "session", Value: token})
+			},
+		},
+		{
+			name: "custom header",
+			prepare: func(r *http.Request) {
+				r.Header.Set("X-Token", token)
+			},
+		},
+		{
+			name: "basic scheme is ignored",
+			prepare: func(r *http.Request) {
+				r.Header.Set("Authorization", "Basic "+token)
+			},
+			err: auth.ErrRequestNotAcceptable,
+		},
+		{
+			name: "default header is not a source",
+			prepare: func(r *http.Request) {
+				r.Header.Set("X-Auth-Token", token)
+			},
+			err: auth.ErrRequestNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			tt.prepare(req)
+
+			claims, err := decoder.DecodeToken(req)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, verifierClaims.Email, claims.Email)
+		})
+	}
+}
+
+func TestDecoderValidation(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	v, err := auth.NewVerifier(context.Background(),
+		auth.WithPublicKeyPEM("rsa", TestRSAPublicKey),
+		auth.WithPublicKeyPEM("ec", encodePublicKeyPEM(t, &ecKey.PublicKey)),
+		auth.WithPublicKeyPEM("ed", encodePublicKeyPEM(t, edKey.Public())),
+	)
+	require.NoError(t, err)
+	defer v.Close()
+
+	decoder := auth.NewDecoder(
+		auth.WithVerifier(v),
+		auth.WithIssuers("https://auth.pomelo.la"),
+		auth.WithAudiences("usercontextapi", "servicecontextapi"),
+		auth.WithLeeway(time.Minute),
+		auth.WithRequiredClaims("tenant", "exp"),
+	)
+
+	now := time.Now()
+	valid := func() customClaims {
+		return customClaims{
+			Claims: auth.Claims{
+				Area:  []string{"issuing"},
+				Email: "example@pomelo.la",
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    "https://auth.pomelo.la",
+					Audience:  jwt.ClaimStrings{"usercontextapi"},
+					ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+					IssuedAt:  jwt.NewNumericDate(now),
+				},
+			},
+			Tenant: "pomelo",
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    crypto.PrivateKey
+		modify func(c *customClaims)
+		err    error
+	}{
+		{
+			name:   "valid rs256",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+		},
+		{
+			name:   "valid es256",
+			method: jwt.SigningMethodES256,
+			key:    ecKey,
+		},
+		{
+			name:   "valid eddsa",
+			method: jwt.SigningMethodEdDSA,
+			key:    edKey,
+		},
+		{
+			name:   "algorithm not allowed",
+			method: jwt.SigningMethodRS512,
+			key:    rsaKey,
+			err:    auth.ErrAlgorithmNotAllowed,
+		},
+		{
+			name:   "bad signature",
+			method: jwt.SigningMethodES256,
+			key:    otherKey,
+			err:    auth.ErrInvalidSignature,
+		},
+		{
+			name:   "expired within leeway",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-30 * time.Second))
+			},
+		},
+		{
+			name:   "expired",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.ExpiresAt = jwt.NewNumericDate(now.Add(-2 * time.Minute))
+			},
+			err: auth.ErrTokenExpired,
+		},
+		{
+			name:   "not before in the future",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.NotBefore = jwt.NewNumericDate(now.Add(2 * time.Minute))
+			},
+			err: auth.ErrTokenNotValidYet,
+		},
+		{
+			name:   "issued in the future",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.IssuedAt = jwt.NewNumericDate(now.Add(2 * time.Minute))
+			},
+			err: auth.ErrTokenNotValidYet,
+		},
+		{
+			name:   "untrusted issuer",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.Issuer = "https://evil.example"
+			},
+			err: auth.ErrInvalidIssuer,
+		},
+		{
+			name:   "unexpected audience",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.Audience = jwt.ClaimStrings{"othercontextapi"}
+			},
+			err: auth.ErrInvalidAudience,
+		},
+		{
+			name:   "missing custom claim",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.Tenant = ""
+			},
+			err: auth.ErrMissingClaim,
+		},
+		{
+			name:   "missing registered claim",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.ExpiresAt = nil
+			},
+			err: auth.ErrMissingClaim,
+		},
+		{
+			name:   "missing areas",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			modify: func(c *customClaims) {
+				c.Area = nil
+			},
+			err: auth.ErrMissingAreas,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			if tt.modify != nil {
+				tt.modify(&claims)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-Auth-Token", signClaims(t, tt.method, tt.key, claims))
+
+			got, err := decoder.DecodeToken(req)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, claims.Email, got.Email)
+		})
+	}
+}
+
+func TestDecoderErrorsWrapLegacyErrors(t *testing.T) {
+	assert.ErrorIs(t, auth.ErrTokenExpired, auth.ErrUnauthorized)
+	assert.ErrorIs(t, auth.ErrMissingClaim, auth.ErrUnauthorized)
+	assert.ErrorIs(t, auth.ErrInvalidSignature, auth.ErrRequestNotAcceptable)
+}
+
+func TestDecoderMiddleware(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	handler := auth.NewDecoder(auth.WithTokenSources(auth.FromBearer)).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, ok := auth.ClaimsFromContext(r.Context())
+			assert.True(t, ok)
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, verifierClaims))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	expired := verifierClaims
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, expired))
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
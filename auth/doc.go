@@ -1,6 +1,89 @@
 /*
 Package auth provides capabilities to working with Pomelo internal auth with sane configurations.
 
+# Middleware
+
+Middleware validates the token of every request and stores its Identity and
+claims in the request context, which handlers read with IdentityFromContext
+and ClaimsFromContext. RequireRole, RequireArea and RequireService guard routes
+based on those claims.
+
+	r.Use(auth.Middleware)
+	r.With(auth.RequireRole("admin")).Delete("/cards/{id}", deleteCard)
+
+The request headers are left untouched. Services that still read the claims
+from the business-units, owner and role headers can opt in to them with
+SetLegacyHeaders or the WithLegacyHeaders option of the Decoder.
+
+Forward, or an http.Client using ForwardingTransport, propagates the token
+of the identity to downstream calls.
+
+	client := &http.Client{Transport: auth.ForwardingTransport(nil)}
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, cardsURL, nil)
+	resp, err := client.Do(req)
+
+# Policy
+
+A Policy declares which requests each route authorizes instead of checking
+the claims in every handler. Rules are evaluated in order against the route
+pattern, and the first one matching decides; requests satisfying none of its
+grants are answered with HTTP 403, logged and recorded as a span event.
+
+	policy := auth.NewPolicy([]auth.Rule{
+		auth.Allow(http.MethodPost, "/cards", auth.Roles("admin").InArea("issuing"), auth.Services("cards-api")),
+		auth.Allow("", "/admin/*", auth.Roles("admin")),
+	}, auth.WithPolicyLogger(log))
+
+	r.Use(auth.Middleware, policy.Middleware)
+
+# Verifier
+
+DecodeToken verifies tokens with the single RSA key set in the
+CONTEXT_API_PUBLIC_KEY environment variable. A Verifier trusts several RSA,
+ECDSA or Ed25519 keys at once, picked by the kid header of the token, and
+loads them from PEM or from a JWKS document served over HTTP or stored in a
+file. The JWKS document is refreshed in the background and whenever a token
+references an unknown key, so signing keys can be rotated without downtime.
+
+	v, err := auth.NewVerifier(ctx, auth.WithJWKSURL("https://auth.pomelo.la/.well-known/jwks.json"))
+	if err != nil {
+		return err
+	}
+	defer v.Close()
+
+	r.Use(v.Middleware)
+
+# Decoder
+
+A Decoder reads the token from the Authorization header, a cookie or any
+header, see TokenSource, and validates its issuer, audience, signing algorithm
+and required claims, tolerating some clock skew on the exp, nbf and iat claims.
+
+	d := auth.NewDecoder(
+		auth.WithVerifier(v),
+		auth.WithTokenSources(auth.FromBearer, auth.FromCookie("session")),
+		auth.WithIssuers("https://auth.pomelo.la"),
+		auth.WithLeeway(30*time.Second),
+		auth.WithRequiredClaims("tenant"),
+	)
+
+	r.Use(d.Middleware)
+
+Its errors tell apart the reason a token was rejected, while still wrapping
+ErrUnauthorized or ErrRequestNotAcceptable.
+
+# Signer
+
+A Signer issues tokens with the shape of the Claims from an RSA or ECDSA
+private key, e.g. for internal tools, tests or service-to-service calls.
+
+	s, err := auth.NewSignerFromPEM(privateKey, auth.WithKeyID("2024-01"), auth.WithTTL(15*time.Minute))
+	if err != nil {
+		return err
+	}
+
+	token, err := s.ServiceToken("cards-api", "issuing")
+
 # Errors exposed by the package
 
 - ErrRequestNotAcceptable
@@ -12,5 +95,25 @@ Package auth provides capabilities to working with Pomelo internal auth with san
 - ErrMissingAreas
 
 - ErrMissingEmail
+
+- ErrMissingKeySource
+
+- ErrInvalidPublicKey
+
+- ErrTokenExpired
+
+- ErrTokenNotValidYet
+
+- ErrInvalidSignature
+
+- ErrAlgorithmNotAllowed
+
+- ErrInvalidIssuer
+
+- ErrInvalidAudience
+
+- ErrMissingClaim
+
+- ErrInvalidPrivateKey
 */
 package auth
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"slices"
+)
+
+// Principal is the kind of party a token was issued to.
+type Principal string
+
+const (
+	// PrincipalUser identifies tokens issued to a person.
+	PrincipalUser Principal = "user"
+	// PrincipalService identifies service-to-service tokens.
+	PrincipalService Principal = "service"
+)
+
+// Identity is the authenticated party of a request, as stated by its token.
+type Identity struct {
+	// Principal is the kind of party the token was issued to.
+	Principal Principal
+	// Areas specifies the business units of Pomelo company.
+	Areas []string
+	// Roles specifies the roles of the user.
+	Roles []string
+	// Email specifies the email of the user, empty for services.
+	Email string
+	// ServiceName specifies the name of the service, empty for users.
+	ServiceName string
+
+	token string
+}
+
+func newIdentity(claims *Claims, token string) *Identity {
+	identity := &Identity{
+		Principal:   PrincipalUser,
+		Areas:       claims.Area,
+		Roles:       claims.Role,
+		Email:       claims.Email,
+		ServiceName: claims.ServiceName,
+		token:       token,
+	}
+	if claims.IsService() {
+		identity.Principal = PrincipalService
+	}
+
+	return identity
+}
+
+// IsService reports whether the identity is a service principal.
+func (i *Identity) IsService() bool {
+	return i.Principal == PrincipalService
+}
+
+// Owner returns the email of a user or the name of a service.
+func (i *Identity) Owner() string {
+	if i.IsService() {
+		return i.ServiceName
+	}
+
+	return i.Email
+}
+
+// HasRole reports whether the identity is granted any of the given roles.
+func (i *Identity) HasRole(roles ...string) bool {
+	return slices.ContainsFunc(roles, func(role string) bool {
+		return slices.Contains(i.Roles, role)
+	})
+}
+
+// HasArea reports whether the identity belongs to any of the given areas.
+func (i *Identity) HasArea(areas ...string) bool {
+	return slices.ContainsFunc(areas, func(area string) bool {
+		return slices.Contains(i.Areas, area)
+	})
+}
+
+// Token returns the raw token the identity was decoded from.
+func (i *Identity) Token() string {
+	return i.token
+}
+
+// ContextWithIdentity returns a copy of ctx carrying the given identity.
+func ContextWithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+// IdentityFromContext returns the identity stored in ctx by Middleware, if any.
+func IdentityFromContext(ctx context.Context) (*Identity, bool) {
+	identity, ok := ctx.Value(identityKey).(*Identity)
+	return identity, ok && identity != nil
+}
+
+// Forward sets the token of the identity stored in ctx in the X-Auth-Token
+// header of a downstream request, so that the downstream service decodes the
+// same identity. It reports whether ctx carried an identity.
+func Forward(ctx context.Context, req *http.Request) bool {
+	identity, ok := IdentityFromContext(ctx)
+	if !ok || identity.token == "" {
+		return false
+	}
+
+	req.Header.Set(_tokenHeader, identity.token)
+	return true
+}
+
+// ForwardingTransport wraps base, or http.DefaultTransport if nil, so that
+// every request forwards the identity stored in its context, see Forward.
+func ForwardingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		if _, ok := IdentityFromContext(req.Context()); ok {
+			// A RoundTripper must not modify the given request.
+			req = req.Clone(req.Context())
+			Forward(req.Context(), req)
+		}
+
+		return base.RoundTrip(req)
+	})
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
+
+// IdentityKey returns the owner of the identity stored in the request
+// context, or an empty string. It can be used as an httprouter.RateLimitKeyFunc
+// to track quotas by token owner.
+func IdentityKey(r *http.Request) string {
+	identity, ok := IdentityFromContext(r.Context())
+	if !ok {
+		return ""
+	}
+
+	return identity.Owner()
+}
@@ -0,0 +1,175 @@
+package auth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/auth"
+)
+
+func TestMiddlewareIdentity(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	signer, err := auth.NewSignerFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+
+	userToken, err := signer.UserToken("example@pomelo.la", []string{"issuing"}, "admin")
+	require.NoError(t, err)
+	serviceToken, err := signer.ServiceToken("cards-api", "issuing")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+		want  auth.Identity
+		owner string
+	}{
+		{
+			name:  "user",
+			token: userToken,
+			want: auth.Identity{
+				Principal: auth.PrincipalUser,
+				Areas:     []string{"issuing"},
+				Roles:     []string{"admin"},
+				Email:     "example@pomelo.la",
+			},
+			owner: "example@pomelo.la",
+		},
+		{
+			name:  "service",
+			token: serviceToken,
+			want: auth.Identity{
+				Principal:   auth.PrincipalService,
+				Areas:       []string{"issuing"},
+				ServiceName: "cards-api",
+			},
+			owner: "cards-api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *auth.Identity
+			handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				identity, ok := auth.IdentityFromContext(r.Context())
+				require.True(t, ok)
+				got = identity
+
+				assert.Equal(t, tt.owner, auth.IdentityKey(r))
+				assert.Empty(t, r.Header.Get(auth.Owner))
+			}))
+
+			handler.ServeHTTP(httptest.NewRecorder(), requestWithToken(tt.token))
+
+			require.NotNil(t, got)
+			assert.Equal(t, tt.want.Principal, got.Principal)
+			assert.Equal(t, tt.want.Areas, got.Areas)
+			assert.Equal(t, tt.want.Roles, got.Roles)
+			assert.Equal(t, tt.want.Email, got.Email)
+			assert.Equal(t, tt.want.ServiceName, got.ServiceName)
+			assert.Equal(t, tt.owner, got.Owner())
+			assert.Equal(t, tt.token, got.Token())
+		})
+	}
+}
+
+func TestDecoderLegacyHeaders(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	signer, err := auth.NewSignerFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+	serviceToken, err := signer.ServiceToken("cards-api", "issuing")
+	require.NoError(t, err)
+	userToken, err := signer.UserToken("example@pomelo.la", []string{"issuing"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+		want  http.Header
+	}{
+		{
+			name:  "service",
+			token: serviceToken,
+			want: http.Header{
+				auth.BusinessUnits: {"issuing"},
+				auth.Owner:         {"cards-api"},
+				auth.Role:          {"service_cards-api"},
+			},
+		},
+		{
+			name:  "user without roles",
+			token: userToken,
+			want: http.Header{
+				auth.BusinessUnits: {"issuing"},
+				auth.Owner:         {"example@pomelo.la"},
+				auth.Role:          nil,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := auth.NewDecoder(auth.WithLegacyHeaders()).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for name, values := range tt.want {
+					assert.Equal(t, values, r.Header.Values(name), name)
+				}
+			}))
+
+			req := requestWithToken(tt.token)
+			// Spoofed headers are replaced by the claims of the token.
+			req.Header.Set(auth.Owner, "someone-else")
+			req.Header.Add(auth.BusinessUnits, "treasury")
+			req.Header.Set(auth.Role, "admin")
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+			assert.Equal(t, http.StatusOK, res.Code)
+		})
+	}
+}
+
+func TestForward(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	signer, err := auth.NewSignerFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+	token, err := signer.UserToken("example@pomelo.la", []string{"issuing"})
+	require.NoError(t, err)
+
+	downstream := httptest.NewServer(auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, ok := auth.IdentityFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "example@pomelo.la", identity.Email)
+	})))
+	defer downstream.Close()
+
+	client := &http.Client{Transport: auth.ForwardingTransport(nil)}
+
+	handler := auth.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, downstream.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, req.Header.Get("X-Auth-Token"))
+
+		manual, err := http.NewRequest(http.MethodGet, downstream.URL, nil)
+		require.NoError(t, err)
+		assert.True(t, auth.Forward(r.Context(), manual))
+		assert.Equal(t, token, manual.Header.Get("X-Auth-Token"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), requestWithToken(token))
+
+	req, err := http.NewRequest(http.MethodGet, downstream.URL, nil)
+	require.NoError(t, err)
+	assert.False(t, auth.Forward(context.Background(), req))
+}
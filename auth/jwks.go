@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrInvalidPublicKey indicates that a public key could not be parsed.
+var ErrInvalidPublicKey = errors.New("invalid public key")
+
+// jwks is a JSON Web Key Set.
+//
+// RFC for more info https://datatracker.ietf.org/doc/html/rfc7517#section-5
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// jwk is a JSON Web Key holding a public key.
+//
+// RFC for more info https://datatracker.ietf.org/doc/html/rfc7518#section-6
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// verificationKey is a public key along with the algorithm it is restricted
+// to, if any.
+type verificationKey struct {
+	key crypto.PublicKey
+	alg string
+}
+
+// parseJWKS parses a JSON Web Key Set into verification keys by key ID.
+// Keys that are not meant for signatures or whose type is not supported
+// are skipped.
+func parseJWKS(data []byte) (map[string]verificationKey, error) {
+	var set jwks
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parsing jwks: %w", err)
+	}
+
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if errors.Is(err, errUnsupportedKey) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing jwk %q: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = verificationKey{key: key, alg: k.Alg}
+	}
+
+	return keys, nil
+}
+
+var errUnsupportedKey = errors.New("unsupported key type")
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, err := ellipticCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, ErrInvalidPublicKey
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errUnsupportedKey
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidPublicKey
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+func ellipticCurve(crv string) (elliptic.Curve, error) {
+	switch crv {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidPublicKey
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// parsePublicKeyPEM parses a PEM encoded RSA, ECDSA or Ed25519 public key.
+func parsePublicKeyPEM(pemKey []byte) (crypto.PublicKey, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(pemKey); err == nil {
+		return key, nil
+	}
+
+	if key, err := jwt.ParseECPublicKeyFromPEM(pemKey); err == nil {
+		return key, nil
+	}
+
+	if key, err := jwt.ParseEdPublicKeyFromPEM(pemKey); err == nil {
+		return key, nil
+	}
+
+	return nil, ErrInvalidPublicKey
+}
+
+// matchesMethod reports whether the key can verify tokens signed with the
+// given method, preventing algorithm confusion.
+func (k verificationKey) matchesMethod(method jwt.SigningMethod) bool {
+	if k.alg != "" && k.alg != method.Alg() {
+		return false
+	}
+
+	switch k.key.(type) {
+	case *rsa.PublicKey:
+		_, isRSA := method.(*jwt.SigningMethodRSA)
+		_, isPSS := method.(*jwt.SigningMethodRSAPSS)
+		return isRSA || isPSS
+	case *ecdsa.PublicKey:
+		_, ok := method.(*jwt.SigningMethodECDSA)
+		return ok
+	case ed25519.PublicKey:
+		_, ok := method.(*jwt.SigningMethodEd25519)
+		return ok
+	default:
+		return false
+	}
+}
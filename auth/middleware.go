@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+type ctxKey int
+
+const (
+	claimsKey ctxKey = iota + 1
+	identityKey
+)
+
+// ContextWithClaims returns a copy of ctx carrying the given claims.
+func ContextWithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// ClaimsFromContext returns the claims stored in ctx by Middleware, if any.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// HasRole reports whether the claims grant any of the given roles.
+func (c *Claims) HasRole(roles ...string) bool {
+	return slices.ContainsFunc(roles, func(role string) bool {
+		return slices.Contains(c.Role, role)
+	})
+}
+
+// HasArea reports whether the claims belong to any of the given areas.
+func (c *Claims) HasArea(areas ...string) bool {
+	return slices.ContainsFunc(areas, func(area string) bool {
+		return slices.Contains(c.Area, area)
+	})
+}
+
+// IsService reports whether the claims belong to a service token.
+func (c *Claims) IsService() bool {
+	return c.VerifyAudience(ServiceContextAPI, true)
+}
+
+// Middleware validates the Pomelo token of every request with DecodeToken and
+// stores the resulting Identity and claims in the request context, see
+// IdentityFromContext and ClaimsFromContext.
+//
+// Requests without a token or with a bad signature are answered with HTTP 406,
+// any other invalid token is answered with HTTP 401, in the format of the
+// Router serving the request.
+func Middleware(next http.Handler) http.Handler {
+	return decodeMiddleware(decodeEnvToken, false)(next)
+}
+
+func decodeMiddleware(decode func(r *http.Request) (*jwt.Token, error), legacyHeaders bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return httprouter.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			token, err := decode(r)
+			if err != nil {
+				return httprouter.WrapError(decodeErrorStatus(err), err)
+			}
+
+			claims := token.Claims.(*Claims)
+			if legacyHeaders {
+				SetLegacyHeaders(r, claims)
+			}
+
+			ctx := ContextWithClaims(r.Context(), claims)
+			ctx = ContextWithIdentity(ctx, newIdentity(claims, token.Raw))
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return nil
+		})
+	}
+}
+
+// RequireRole produces a middleware that only lets through requests whose
+// claims grant any of the given roles. It must be used after Middleware.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return requireClaims(func(claims *Claims) bool {
+		return claims.HasRole(roles...)
+	}, "insufficient role")
+}
+
+// RequireArea produces a middleware that only lets through requests whose
+// claims belong to any of the given areas. It must be used after Middleware.
+func RequireArea(areas ...string) func(http.Handler) http.Handler {
+	return requireClaims(func(claims *Claims) bool {
+		return claims.HasArea(areas...)
+	}, "area not allowed")
+}
+
+// RequireService produces a middleware that only lets through requests made
+// with a service token issued to any of the given services. It must be used
+// after Middleware.
+func RequireService(services ...string) func(http.Handler) http.Handler {
+	return requireClaims(func(claims *Claims) bool {
+		return claims.IsService() && slices.Contains(services, claims.ServiceName)
+	}, "service not allowed")
+}
+
+// requireClaims answers HTTP 401 to requests without claims and HTTP 403 to those
+// whose claims do not satisfy the given guard.
+func requireClaims(guard func(claims *Claims) bool, message string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return httprouter.Handler(func(w http.ResponseWriter, r *http.Request) error {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				return httprouter.NewError(http.StatusUnauthorized, ErrUnauthorized.Error())
+			}
+
+			if !guard(claims) {
+				return httprouter.NewError(http.StatusForbidden, message)
+			}
+
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
+
+func decodeErrorStatus(err error) int {
+	if errors.Is(err, ErrRequestNotAcceptable) {
+		return http.StatusNotAcceptable
+	}
+
+	return http.StatusUnauthorized
+}
@@ -0,0 +1,218 @@
+package auth_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/auth"
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func signToken(t *testing.T, claims auth.Claims) string {
+	t.Helper()
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	require.NoError(t, err)
+
+	return token
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	userClaims := auth.Claims{Area: []string{"issuing"}, Email: "example@pomelo.la", Role: []string{"admin"}}
+	svcClaims := auth.Claims{
+		Area:             []string{"issuing"},
+		ServiceName:      "cards-api",
+		RegisteredClaims: jwt.RegisteredClaims{Audience: jwt.ClaimStrings{auth.ServiceContextAPI}},
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		middlewares []func(http.Handler) http.Handler
+		wantStatus  int
+		wantError   string
+	}{
+		{
+			name:       "missing token",
+			wantStatus: http.StatusNotAcceptable,
+			wantError:  "not_acceptable",
+		},
+		{
+			name:       "expired token",
+			token:      signToken(t, auth.Claims{Area: []string{"issuing"}, Email: "example@pomelo.la", RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Hour))}}),
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "unauthorized",
+		},
+		{
+			name:       "missing areas",
+			token:      signToken(t, auth.Claims{Email: "example@pomelo.la"}),
+			wantStatus: http.StatusUnauthorized,
+			wantError:  "unauthorized",
+		},
+		{
+			name:       "valid user token",
+			token:      signToken(t, userClaims),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:        "user with required role",
+			token:       signToken(t, userClaims),
+			middlewares: []func(http.Handler) http.Handler{auth.RequireRole("viewer", "admin")},
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "user without required role",
+			token:       signToken(t, userClaims),
+			middlewares: []func(http.Handler) http.Handler{auth.RequireRole("superadmin")},
+			wantStatus:  http.StatusForbidden,
+			wantError:   "forbidden",
+		},
+		{
+			name:        "user with required area",
+			token:       signToken(t, userClaims),
+			middlewares: []func(http.Handler) http.Handler{auth.RequireArea("issuing")},
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "user without required area",
+			token:       signToken(t, userClaims),
+			middlewares: []func(http.Handler) http.Handler{auth.RequireArea("acquiring")},
+			wantStatus:  http.StatusForbidden,
+			wantError:   "forbidden",
+		},
+		{
+			name:        "user is not a service",
+			token:       signToken(t, userClaims),
+			middlewares: []func(http.Handler) http.Handler{auth.RequireService("cards-api")},
+			wantStatus:  http.StatusForbidden,
+			wantError:   "forbidden",
+		},
+		{
+			name:        "allowed service",
+			token:       signToken(t, svcClaims),
+			middlewares: []func(http.Handler) http.Handler{auth.RequireService("cards-api")},
+			wantStatus:  http.StatusOK,
+		},
+		{
+			name:        "service not allowed",
+			token:       signToken(t, svcClaims),
+			middlewares: []func(http.Handler) http.Handler{auth.RequireService("users-api")},
+			wantStatus:  http.StatusForbidden,
+			wantError:   "forbidden",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := httprouter.New()
+			app.Use(auth.Middleware)
+			app.With(tc.middlewares...).Get("/", func(w http.ResponseWriter, r *http.Request) error {
+				claims, ok := auth.ClaimsFromContext(r.Context())
+				require.True(t, ok)
+				return httprouter.RespondJSON(w, http.StatusOK, claims)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.token != "" {
+				request.Header.Set("X-Auth-Token", tc.token)
+			}
+			recorder := httptest.NewRecorder()
+
+			app.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.wantStatus, recorder.Code)
+			if tc.wantError != "" {
+				var body httprouter.Error
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+				assert.Equal(t, tc.wantError, body.Code)
+			}
+		})
+	}
+}
+
+func TestRequireWithoutMiddleware(t *testing.T) {
+	app := httprouter.New()
+	app.With(auth.RequireRole("admin")).Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusOK, nil)
+	})
+
+	recorder := httptest.NewRecorder()
+	app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestMiddlewareRouterErrors(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	var handled error
+	app := httprouter.New(
+		httprouter.WithProblemDetails(true),
+		httprouter.WithErrorHandlerFunc(func(err error, defaultHandlerError func(error) httprouter.HandlerError) httprouter.HandlerError {
+			handled = err
+			return defaultHandlerError(err)
+		}),
+	)
+	app.Use(auth.Middleware)
+	app.With(auth.RequireRole("superadmin")).Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusOK, nil)
+	})
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantCause  error
+	}{
+		{
+			name:       "missing token",
+			wantStatus: http.StatusNotAcceptable,
+			wantCause:  auth.ErrRequestNotAcceptable,
+		},
+		{
+			name:       "user without required role",
+			token:      signToken(t, auth.Claims{Area: []string{"issuing"}, Email: "example@pomelo.la", Role: []string{"admin"}}),
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handled = nil
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.token != "" {
+				request.Header.Set("X-Auth-Token", tc.token)
+			}
+			recorder := httptest.NewRecorder()
+
+			app.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.wantStatus, recorder.Code)
+			assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+
+			var problem map[string]any
+			require.NoError(t, json.NewDecoder(recorder.Body).Decode(&problem))
+			assert.Equal(t, float64(tc.wantStatus), problem["status"])
+
+			require.Error(t, handled)
+			if tc.wantCause != nil {
+				assert.ErrorIs(t, handled, tc.wantCause)
+			}
+		})
+	}
+}
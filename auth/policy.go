@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+	"github.com/pomelo-la/go-toolkit/logger"
+)
+
+// Grant is a requirement a request may satisfy to be authorized. Every field
+// that is set must be satisfied; an empty Grant is satisfied by any valid token.
+type Grant struct {
+	// Roles grants access to users with any of the roles.
+	Roles []string
+	// Services grants access to service tokens issued to any of the services.
+	Services []string
+	// Areas restricts the grant to tokens belonging to any of the areas.
+	Areas []string
+}
+
+// Roles returns a Grant for users with any of the given roles.
+func Roles(roles ...string) Grant {
+	return Grant{Roles: roles}
+}
+
+// Services returns a Grant for service tokens issued to any of the given services.
+func Services(services ...string) Grant {
+	return Grant{Services: services}
+}
+
+// Areas returns a Grant for tokens belonging to any of the given areas.
+func Areas(areas ...string) Grant {
+	return Grant{Areas: areas}
+}
+
+// InArea returns a copy of the Grant restricted to tokens belonging to any of
+// the given areas.
+func (g Grant) InArea(areas ...string) Grant {
+	g.Areas = areas
+	return g
+}
+
+// satisfiedBy reports whether the claims satisfy the grant.
+func (g Grant) satisfiedBy(claims *Claims) bool {
+	if len(g.Areas) > 0 && !claims.HasArea(g.Areas...) {
+		return false
+	}
+
+	switch {
+	case len(g.Roles) > 0 && len(g.Services) > 0:
+		return g.grantsUser(claims) || g.grantsService(claims)
+	case len(g.Roles) > 0:
+		return g.grantsUser(claims)
+	case len(g.Services) > 0:
+		return g.grantsService(claims)
+	default:
+		return true
+	}
+}
+
+func (g Grant) grantsUser(claims *Claims) bool {
+	return !claims.IsService() && claims.HasRole(g.Roles...)
+}
+
+func (g Grant) grantsService(claims *Claims) bool {
+	return claims.IsService() && slices.Contains(g.Services, claims.ServiceName)
+}
+
+// Rule authorizes the requests to a route that satisfy any of its grants.
+type Rule struct {
+	// Method is the HTTP method of the route, any method if empty.
+	Method string
+	// Pattern is the route pattern as registered in the router, e.g.
+	// "/cards/{id}". A pattern ending in "/*" matches every route under it.
+	Pattern string
+	// Allow holds the grants of the rule, any of which authorizes a request.
+	Allow []Grant
+}
+
+// Allow returns a Rule for the given method and route pattern authorizing the
+// requests that satisfy any of the given grants, e.g.
+//
+//	auth.Allow(http.MethodPost, "/cards", auth.Roles("admin").InArea("issuing"), auth.Services("cards-api"))
+func Allow(method, pattern string, grants ...Grant) Rule {
+	return Rule{
+		Method:  method,
+		Pattern: pattern,
+		Allow:   grants,
+	}
+}
+
+func (rule Rule) matches(method, pattern string) bool {
+	if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+		return false
+	}
+
+	if prefix, ok := strings.CutSuffix(rule.Pattern, "*"); ok {
+		return strings.HasPrefix(pattern, prefix) || pattern == strings.TrimSuffix(prefix, "/")
+	}
+
+	return rule.Pattern == pattern
+}
+
+// Decision is the outcome of evaluating a Policy for a request.
+type Decision struct {
+	// Allowed reports whether the request is authorized.
+	Allowed bool
+	// Rule is the rule that matched the request, nil if none did.
+	Rule *Rule
+	// Reason describes why the request was denied.
+	Reason string
+}
+
+// PolicyOptions represents the options for configuring a Policy.
+type PolicyOptions struct {
+	Logger        *logger.Logger
+	DenyUnmatched bool
+}
+
+// WithPolicyLogger allows you to configure the logger in which denied requests
+// are logged.
+func WithPolicyLogger(log *logger.Logger) func(opts *PolicyOptions) {
+	return func(opts *PolicyOptions) {
+		opts.Logger = log
+	}
+}
+
+// WithDenyUnmatched makes the Policy deny the requests to routes no rule
+// matches. Default behavior is to let them through.
+func WithDenyUnmatched() func(opts *PolicyOptions) {
+	return func(opts *PolicyOptions) {
+		opts.DenyUnmatched = true
+	}
+}
+
+// Policy is a declarative set of authorization rules evaluated against the
+// claims of a request. Rules are evaluated in order and the first one matching
+// the route decides.
+type Policy struct {
+	rules []Rule
+	opts  PolicyOptions
+}
+
+// NewPolicy instantiates a Policy with the given rules.
+func NewPolicy(rules []Rule, optFns ...func(opts *PolicyOptions)) *Policy {
+	var opts PolicyOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &Policy{
+		rules: rules,
+		opts:  opts,
+	}
+}
+
+// Evaluate decides whether the claims are authorized to the route with the
+// given method and pattern. Nil claims are only authorized to routes no rule
+// matches, unless the Policy denies those.
+func (p *Policy) Evaluate(method, pattern string, claims *Claims) Decision {
+	for i := range p.rules {
+		rule := &p.rules[i]
+		if !rule.matches(method, pattern) {
+			continue
+		}
+
+		if claims == nil {
+			return Decision{Rule: rule, Reason: "missing claims"}
+		}
+
+		if slices.ContainsFunc(rule.Allow, func(grant Grant) bool {
+			return grant.satisfiedBy(claims)
+		}) {
+			return Decision{Allowed: true, Rule: rule}
+		}
+
+		return Decision{Rule: rule, Reason: "no grant satisfied"}
+	}
+
+	if p.opts.DenyUnmatched {
+		return Decision{Reason: "no rule matched"}
+	}
+
+	return Decision{Allowed: true}
+}
+
+// Middleware enforces the Policy on every request. It must be used after
+// Middleware, which stores the claims in the request context.
+//
+// Requests without claims to a route a rule matches are answered with HTTP
+// 401, and those not authorized with HTTP 403, in the format of the Router
+// serving the request. Requests to routes that cannot be found are denied as
+// well when any rule applies to their method. Denied requests are logged and
+// recorded as an event of the span in the request context.
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return httprouter.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		claims, _ := ClaimsFromContext(r.Context())
+		pattern, found := routePattern(r)
+
+		decision := p.Evaluate(r.Method, pattern, claims)
+		if !found && decision.Rule == nil && p.hasRules(r.Method) {
+			// The route is unknown, so it cannot be told apart from the ones
+			// the rules of the method protect.
+			decision = Decision{Reason: "route not found"}
+		}
+		if decision.Allowed {
+			next.ServeHTTP(w, r)
+			return nil
+		}
+
+		p.recordDenial(r, pattern, claims, decision)
+
+		if claims == nil {
+			return httprouter.NewError(http.StatusUnauthorized, ErrUnauthorized.Error())
+		}
+
+		return httprouter.NewError(http.StatusForbidden, "access denied")
+	})
+}
+
+// hasRules reports whether any rule applies to method.
+func (p *Policy) hasRules(method string) bool {
+	return slices.ContainsFunc(p.rules, func(rule Rule) bool {
+		return rule.Method == "" || strings.EqualFold(rule.Method, method)
+	})
+}
+
+func (p *Policy) recordDenial(r *http.Request, pattern string, claims *Claims, decision Decision) {
+	owner := ""
+	if claims != nil {
+		owner = newIdentity(claims, "").Owner()
+	}
+
+	trace.SpanFromContext(r.Context()).AddEvent("auth.policy.denied", trace.WithAttributes(
+		attribute.String("http.method", r.Method),
+		attribute.String("http.route", pattern),
+		attribute.String("auth.owner", owner),
+		attribute.String("auth.reason", decision.Reason),
+	))
+
+	if p.opts.Logger == nil {
+		return
+	}
+
+	p.opts.Logger.Warn(r.Context(), "authorization denied",
+		slog.String("method", r.Method),
+		slog.String("route", pattern),
+		slog.String("owner", owner),
+		slog.String("reason", decision.Reason),
+	)
+}
+
+// routePattern returns the pattern of the route the request is routed to,
+// reporting whether it was found. When the middleware runs before routing,
+// e.g. registered with Use, the route is looked up in the router.
+func routePattern(r *http.Request) (string, bool) {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return r.URL.Path, true
+	}
+
+	pattern := rctx.RoutePattern()
+	if rctx.Routes == nil || (pattern != "" && !strings.HasSuffix(pattern, "*")) {
+		return pattern, true
+	}
+
+	// The path is looked up as chi routes it, which is the escaped one when
+	// it has encoded characters like "%2F".
+	path := rctx.RoutePath
+	if path == "" {
+		path = r.URL.Path
+		if r.URL.RawPath != "" {
+			path = r.URL.RawPath
+		}
+	}
+
+	tctx := chi.NewRouteContext()
+	if !rctx.Routes.Match(tctx, r.Method, path) {
+		return pattern, false
+	}
+
+	tctx.RoutePatterns = append(slices.Clone(rctx.RoutePatterns), tctx.RoutePatterns...)
+	return tctx.RoutePattern(), true
+}
@@ -0,0 +1,234 @@
+package auth_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pomelo-la/go-toolkit/auth"
+	"github.com/pomelo-la/go-toolkit/httprouter"
+	"github.com/pomelo-la/go-toolkit/logger"
+)
+
+var cardsPolicy = []auth.Rule{
+	auth.Allow(http.MethodPost, "/cards", auth.Roles("admin").InArea("issuing"), auth.Services("cards-api")),
+	auth.Allow(http.MethodGet, "/cards/{id}", auth.Areas("issuing", "cards")),
+	auth.Allow("", "/admin/*", auth.Roles("admin")),
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	admin := &auth.Claims{Area: []string{"issuing"}, Role: []string{"admin"}, Email: "admin@pomelo.la"}
+	adminOtherArea := &auth.Claims{Area: []string{"lending"}, Role: []string{"admin"}, Email: "admin@pomelo.la"}
+	service := &auth.Claims{
+		Area:             []string{"lending"},
+		ServiceName:      "cards-api",
+		RegisteredClaims: jwt.RegisteredClaims{Audience: jwt.ClaimStrings{auth.ServiceContextAPI}},
+	}
+	impostor := &auth.Claims{Area: []string{"issuing"}, Role: []string{"admin"}, ServiceName: "cards-api",
+		RegisteredClaims: jwt.RegisteredClaims{Audience: jwt.ClaimStrings{auth.ServiceContextAPI}}}
+
+	tests := []struct {
+		name    string
+		options []func(opts *auth.PolicyOptions)
+		method  string
+		pattern string
+		claims  *auth.Claims
+		allowed bool
+		matched bool
+	}{
+		{name: "role in area", method: http.MethodPost, pattern: "/cards", claims: admin, allowed: true, matched: true},
+		{name: "role in another area", method: http.MethodPost, pattern: "/cards", claims: adminOtherArea, matched: true},
+		{name: "allowed service", method: http.MethodPost, pattern: "/cards", claims: service, allowed: true, matched: true},
+		{name: "service does not get user roles", method: http.MethodGet, pattern: "/admin/users", claims: impostor, matched: true},
+		{name: "area only grant", method: http.MethodGet, pattern: "/cards/{id}", claims: admin, allowed: true, matched: true},
+		{name: "wildcard pattern", method: http.MethodDelete, pattern: "/admin/users/{id}", claims: admin, allowed: true, matched: true},
+		{name: "missing claims", method: http.MethodPost, pattern: "/cards", matched: true},
+		{name: "unmatched route", method: http.MethodGet, pattern: "/users", claims: service, allowed: true},
+		{
+			name:    "unmatched route denied",
+			options: []func(opts *auth.PolicyOptions){auth.WithDenyUnmatched()},
+			method:  http.MethodGet,
+			pattern: "/users",
+			claims:  admin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision := auth.NewPolicy(cardsPolicy, tt.options...).Evaluate(tt.method, tt.pattern, tt.claims)
+
+			assert.Equal(t, tt.allowed, decision.Allowed)
+			assert.Equal(t, tt.matched, decision.Rule != nil)
+			if !tt.allowed {
+				assert.NotEmpty(t, decision.Reason)
+			}
+		})
+	}
+}
+
+func TestPolicyMiddleware(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	signer, err := auth.NewSignerFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+	adminToken, err := signer.UserToken("admin@pomelo.la", []string{"issuing"}, "admin")
+	require.NoError(t, err)
+	viewerToken, err := signer.UserToken("viewer@pomelo.la", []string{"lending"}, "viewer")
+	require.NoError(t, err)
+
+	var logs bytes.Buffer
+	log := logger.NewWithHandler(slog.NewJSONHandler(&logs, nil))
+	policy := auth.NewPolicy(cardsPolicy, auth.WithPolicyLogger(log))
+
+	ok := func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusOK, nil)
+	}
+
+	router := httprouter.New(httprouter.WithGlobalMiddlewares(auth.Middleware, policy.Middleware))
+	router.Post("/cards", ok)
+	router.Get("/cards/{id}", ok)
+	router.Get("/users", ok)
+
+	inline := httprouter.New(httprouter.WithGlobalMiddlewares(auth.Middleware))
+	inline.With(policy.Middleware).Get("/cards/{id}", ok)
+
+	sub := chi.NewRouter()
+	sub.Use(auth.Middleware, policy.Middleware)
+	sub.Route("/admin", func(r chi.Router) {
+		r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		method  string
+		path    string
+		token   string
+		status  int
+	}{
+		{name: "allowed", handler: router, method: http.MethodPost, path: "/cards", token: adminToken, status: http.StatusOK},
+		{name: "forbidden", handler: router, method: http.MethodPost, path: "/cards", token: viewerToken, status: http.StatusForbidden},
+		{name: "path parameters", handler: router, method: http.MethodGet, path: "/cards/123", token: viewerToken, status: http.StatusForbidden},
+		{name: "unmatched route", handler: router, method: http.MethodGet, path: "/users", token: viewerToken, status: http.StatusOK},
+		{name: "inline middleware", handler: inline, method: http.MethodGet, path: "/cards/123", token: adminToken, status: http.StatusOK},
+		{name: "inline middleware forbidden", handler: inline, method: http.MethodGet, path: "/cards/123", token: viewerToken, status: http.StatusForbidden},
+		{name: "subrouter", handler: sub, method: http.MethodGet, path: "/admin/users/1", token: viewerToken, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-Auth-Token", tt.token)
+			res := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.status, res.Code)
+		})
+	}
+
+	assert.Contains(t, logs.String(), `"msg":"authorization denied"`)
+	assert.Contains(t, logs.String(), `"route":"/cards/{id}"`)
+	assert.Contains(t, logs.String(), `"owner":"viewer@pomelo.la"`)
+}
+
+func TestPolicyMiddlewareEscapedPath(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	signer, err := auth.NewSignerFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+	viewerToken, err := signer.UserToken("viewer@pomelo.la", []string{"issuing"}, "viewer")
+	require.NoError(t, err)
+
+	policy := auth.NewPolicy([]auth.Rule{auth.Allow(http.MethodGet, "/cards/{id}", auth.Roles("admin"))})
+
+	mux := chi.NewRouter()
+	mux.Use(auth.Middleware, policy.Middleware)
+	mux.Get("/cards/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.Get("/users", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{name: "plain path", method: http.MethodGet, path: "/cards/a", status: http.StatusForbidden},
+		{name: "encoded slash", method: http.MethodGet, path: "/cards/a%2Fb", status: http.StatusForbidden},
+		{name: "unknown route of a protected method", method: http.MethodGet, path: "/cards/a/b", status: http.StatusForbidden},
+		{name: "unmatched route", method: http.MethodGet, path: "/users", status: http.StatusOK},
+		{name: "unknown route of an unprotected method", method: http.MethodPost, path: "/cards/a/b", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-Auth-Token", viewerToken)
+			res := httptest.NewRecorder()
+
+			mux.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.status, res.Code)
+		})
+	}
+}
+
+func TestPolicyMiddlewareProblemDetails(t *testing.T) {
+	policy := auth.NewPolicy(cardsPolicy)
+
+	router := httprouter.New(
+		httprouter.WithProblemDetails(true),
+		httprouter.WithGlobalMiddlewares(policy.Middleware),
+	)
+	router.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusOK, nil)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/cards", nil)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Equal(t, "application/problem+json", res.Header().Get("Content-Type"))
+
+	var problem map[string]any
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &problem))
+	assert.Equal(t, float64(http.StatusUnauthorized), problem["status"])
+	assert.Equal(t, auth.ErrUnauthorized.Error(), problem["detail"])
+}
+
+func TestPolicyMiddlewareSpanEvent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	policy := auth.NewPolicy(cardsPolicy)
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "request")
+	req := httptest.NewRequest(http.MethodPost, "/cards", nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	span.End()
+
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Len(t, spans[0].Events(), 1)
+
+	event := spans[0].Events()[0]
+	assert.Equal(t, "auth.policy.denied", event.Name)
+	assert.Contains(t, event.Attributes, attribute.String("auth.reason", "missing claims"))
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const _defaultTokenTTL = time.Hour
+
+// ErrInvalidPrivateKey indicates that a private key could not be parsed or is
+// not supported for signing.
+var ErrInvalidPrivateKey = errors.New("invalid private key")
+
+// SignerOptions represents the options for configuring a Signer.
+type SignerOptions struct {
+	KeyID  string
+	TTL    time.Duration
+	Issuer string
+}
+
+// WithKeyID allows you to configure the key ID set in the kid header of every
+// token, so that a Verifier can pick the key to verify it with.
+func WithKeyID(kid string) func(opts *SignerOptions) {
+	return func(opts *SignerOptions) {
+		opts.KeyID = kid
+	}
+}
+
+// WithTTL allows you to configure the lifetime of the tokens whose claims do
+// not set an expiration time.
+//
+// Default behavior is to issue tokens valid for one hour.
+func WithTTL(ttl time.Duration) func(opts *SignerOptions) {
+	return func(opts *SignerOptions) {
+		opts.TTL = ttl
+	}
+}
+
+// WithTokenIssuer allows you to configure the iss claim of the tokens whose
+// claims do not set one.
+func WithTokenIssuer(issuer string) func(opts *SignerOptions) {
+	return func(opts *SignerOptions) {
+		opts.Issuer = issuer
+	}
+}
+
+// Signer issues Pomelo tokens signed with a private key, which a Verifier
+// trusting the matching public key accepts. DecodeToken only accepts the
+// tokens signed with the RSA key whose public key is set in the
+// CONTEXT_API_PUBLIC_KEY environment variable.
+type Signer struct {
+	opts   SignerOptions
+	key    crypto.PrivateKey
+	method jwt.SigningMethod
+}
+
+// NewSigner instantiates a Signer from an *rsa.PrivateKey, signing with RS256,
+// or an *ecdsa.PrivateKey, signing with ES256, ES384 or ES512 depending on
+// its curve.
+func NewSigner(key crypto.PrivateKey, optFns ...func(opts *SignerOptions)) (*Signer, error) {
+	method, err := signingMethod(key)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := SignerOptions{
+		TTL: _defaultTokenTTL,
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return &Signer{
+		opts:   opts,
+		key:    key,
+		method: method,
+	}, nil
+}
+
+// NewSignerFromPEM instantiates a Signer from a PEM encoded RSA or ECDSA private key.
+func NewSignerFromPEM(pemKey []byte, optFns ...func(opts *SignerOptions)) (*Signer, error) {
+	if key, err := jwt.ParseRSAPrivateKeyFromPEM(pemKey); err == nil {
+		return NewSigner(key, optFns...)
+	}
+
+	if key, err := jwt.ParseECPrivateKeyFromPEM(pemKey); err == nil {
+		return NewSigner(key, optFns...)
+	}
+
+	return nil, ErrInvalidPrivateKey
+}
+
+// Sign issues a token carrying the given claims. The issued at time, the
+// expiration time and the issuer are filled in when not set.
+func (s *Signer) Sign(claims Claims) (string, error) {
+	now := time.Now()
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
+	}
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(s.opts.TTL))
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = s.opts.Issuer
+	}
+
+	token := jwt.NewWithClaims(s.method, claims)
+	if s.opts.KeyID != "" {
+		token.Header["kid"] = s.opts.KeyID
+	}
+
+	return token.SignedString(s.key)
+}
+
+// UserToken issues a token for the user with the given email, belonging to
+// the given areas and granted the given roles.
+func (s *Signer) UserToken(email string, areas []string, roles ...string) (string, error) {
+	return s.Sign(Claims{
+		Area:  areas,
+		Email: email,
+		Role:  roles,
+	})
+}
+
+// ServiceToken issues a service-to-service token for the given service,
+// belonging to the given areas. DecodeToken reports such tokens with the role
+// "service_" followed by the service name.
+func (s *Signer) ServiceToken(serviceName string, areas ...string) (string, error) {
+	return s.Sign(Claims{
+		Area:        areas,
+		ServiceName: serviceName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience: jwt.ClaimStrings{ServiceContextAPI},
+		},
+	})
+}
+
+func signingMethod(key crypto.PrivateKey) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve.Params().BitSize {
+		case 256:
+			return jwt.SigningMethodES256, nil
+		case 384:
+			return jwt.SigningMethodES384, nil
+		case 521:
+			return jwt.SigningMethodES512, nil
+		}
+	}
+
+	return nil, ErrInvalidPrivateKey
+}
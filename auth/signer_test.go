@@ -0,0 +1,119 @@
+package auth_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/auth"
+)
+
+func requestWithToken(token string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Auth-Token", token)
+
+	return req
+}
+
+func TestNewSigner(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	_, err = auth.NewSigner(edKey)
+	assert.ErrorIs(t, err, auth.ErrInvalidPrivateKey)
+
+	_, err = auth.NewSignerFromPEM([]byte("not a key"))
+	assert.ErrorIs(t, err, auth.ErrInvalidPrivateKey)
+}
+
+func TestSignerUserToken(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	signer, err := auth.NewSignerFromPEM([]byte(TestRSAPrivateKey),
+		auth.WithTTL(10*time.Minute),
+		auth.WithTokenIssuer("https://auth.pomelo.la"),
+	)
+	require.NoError(t, err)
+
+	token, err := signer.UserToken("example@pomelo.la", []string{"issuing", "cards"}, "admin")
+	require.NoError(t, err)
+
+	req := requestWithToken(token)
+	claims, err := auth.DecodeToken(req)
+	require.NoError(t, err)
+	auth.SetLegacyHeaders(req, claims)
+
+	assert.Equal(t, "example@pomelo.la", claims.Email)
+	assert.Equal(t, []string{"issuing", "cards"}, claims.Area)
+	assert.Equal(t, "https://auth.pomelo.la", claims.Issuer)
+	assert.WithinDuration(t, time.Now().Add(10*time.Minute), claims.ExpiresAt.Time, 5*time.Second)
+	assert.Equal(t, "admin", req.Header.Get(auth.Role))
+	assert.Equal(t, "issuing,cards", req.Header.Get(auth.BusinessUnits))
+}
+
+func TestSignerServiceToken(t *testing.T) {
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+
+	signer, err := auth.NewSignerFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+
+	token, err := signer.ServiceToken("cards-api", "issuing")
+	require.NoError(t, err)
+
+	req := requestWithToken(token)
+	claims, err := auth.DecodeToken(req)
+	require.NoError(t, err)
+	auth.SetLegacyHeaders(req, claims)
+
+	assert.True(t, claims.IsService())
+	assert.Equal(t, "service_cards-api", req.Header.Get(auth.Role))
+	assert.Equal(t, "cards-api", req.Header.Get(auth.Owner))
+	assert.WithinDuration(t, time.Now().Add(time.Hour), claims.ExpiresAt.Time, 5*time.Second)
+}
+
+func TestSignerKeyID(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	v, err := auth.NewVerifier(context.Background(),
+		auth.WithPublicKeyPEM("current", encodePublicKeyPEM(t, &key.PublicKey)),
+		auth.WithPublicKeyPEM("previous", TestRSAPublicKey),
+	)
+	require.NoError(t, err)
+	defer v.Close()
+
+	signer, err := auth.NewSigner(key, auth.WithKeyID("current"))
+	require.NoError(t, err)
+
+	expiresAt := jwt.NewNumericDate(time.Now().Add(time.Minute).Truncate(time.Second))
+	token, err := signer.Sign(auth.Claims{
+		Area:             []string{"issuing"},
+		Email:            "example@pomelo.la",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expiresAt},
+	})
+	require.NoError(t, err)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &auth.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "current", parsed.Header["kid"])
+	assert.Equal(t, jwt.SigningMethodES384.Alg(), parsed.Method.Alg())
+
+	claims, err := v.DecodeToken(requestWithToken(token))
+	require.NoError(t, err)
+	assert.Equal(t, expiresAt.Unix(), claims.ExpiresAt.Unix())
+
+	// DecodeToken only trusts the RSA key of the environment.
+	t.Setenv("CONTEXT_API_PUBLIC_KEY", TestRSAPublicKey)
+	_, err = auth.DecodeToken(requestWithToken(token))
+	assert.Error(t, err)
+}
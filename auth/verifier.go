@@ -0,0 +1,443 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	_defaultRefreshInterval    = 15 * time.Minute
+	_defaultMinRefreshInterval = time.Minute
+	_defaultJWKSTimeout        = 10 * time.Second
+	_maxJWKSSize               = 1 << 20
+)
+
+// ErrMissingKeySource indicates that a Verifier was configured without any key.
+var ErrMissingKeySource = errors.New("verifier requires a public key or a jwks source")
+
+var errUnknownKey = errors.New("no key found to verify the token")
+
+// HTTPRequester is the interface used by the Verifier to fetch a JWKS document.
+// It is satisfied by *http.Client and transport.HTTPRequester.
+type HTTPRequester interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// VerifierOptions represents the options for configuring a Verifier.
+type VerifierOptions struct {
+	JWKSURL             string
+	JWKSFile            string
+	PublicKeys          map[string]string
+	RefreshInterval     time.Duration
+	MinRefreshInterval  time.Duration
+	HTTPClient          HTTPRequester
+	RefreshErrorHandler func(err error)
+}
+
+// WithJWKSURL allows you to configure the URL of the JWKS document holding the
+// public keys. It takes precedence over WithJWKSFile.
+func WithJWKSURL(url string) func(opts *VerifierOptions) {
+	return func(opts *VerifierOptions) {
+		opts.JWKSURL = url
+	}
+}
+
+// WithJWKSFile allows you to configure the path of a file holding the JWKS
+// document with the public keys.
+func WithJWKSFile(path string) func(opts *VerifierOptions) {
+	return func(opts *VerifierOptions) {
+		opts.JWKSFile = path
+	}
+}
+
+// WithPublicKeyPEM allows you to add a PEM encoded RSA, ECDSA or Ed25519 public
+// key identified by the given key ID. Use an empty key ID for tokens without
+// the kid header. This option can be used several times to trust several keys
+// at once, e.g. while rotating the signing key.
+func WithPublicKeyPEM(kid, pemKey string) func(opts *VerifierOptions) {
+	return func(opts *VerifierOptions) {
+		if opts.PublicKeys == nil {
+			opts.PublicKeys = make(map[string]string)
+		}
+		opts.PublicKeys[kid] = pemKey
+	}
+}
+
+// WithRefreshInterval allows you to configure how often the JWKS document is
+// fetched again in the background. A value of zero disables the background
+// refresh.
+//
+// Default behavior is to refresh the keys every 15 minutes.
+func WithRefreshInterval(interval time.Duration) func(opts *VerifierOptions) {
+	return func(opts *VerifierOptions) {
+		opts.RefreshInterval = interval
+	}
+}
+
+// WithMinRefreshInterval allows you to configure the minimum time between two
+// fetches of the JWKS document triggered by tokens signed with an unknown key,
+// whether they succeed or not.
+//
+// Default behavior is to fetch the document at most once a minute.
+func WithMinRefreshInterval(interval time.Duration) func(opts *VerifierOptions) {
+	return func(opts *VerifierOptions) {
+		opts.MinRefreshInterval = interval
+	}
+}
+
+// WithHTTPClient allows you to configure the client used to fetch the JWKS document.
+//
+// Default behavior is to use an *http.Client with a timeout of 10 seconds.
+func WithHTTPClient(client HTTPRequester) func(opts *VerifierOptions) {
+	return func(opts *VerifierOptions) {
+		opts.HTTPClient = client
+	}
+}
+
+// WithRefreshErrorHandler allows you to configure a function that gets called
+// every time a background refresh fails. The previously fetched keys remain
+// valid in that case.
+func WithRefreshErrorHandler(fn func(err error)) func(opts *VerifierOptions) {
+	return func(opts *VerifierOptions) {
+		opts.RefreshErrorHandler = fn
+	}
+}
+
+// Verifier verifies Pomelo tokens against a set of trusted public keys.
+//
+// Keys are selected by the kid header of the token; tokens without it are
+// tried against every key compatible with their signing method. Keys loaded
+// from a JWKS document are parsed once, refreshed in the background and
+// fetched again when a token references an unknown key, which allows to
+// rotate signing keys without downtime.
+type Verifier struct {
+	opts   VerifierOptions
+	static map[string]verificationKey
+
+	mu          sync.RWMutex
+	fetched     map[string]verificationKey
+	lastRefresh time.Time
+	onMiss      *refreshCall
+	refreshMu   sync.Mutex
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewVerifier instantiates a Verifier. When a JWKS source is configured the
+// document is fetched before returning, so that the Verifier is ready to use.
+//
+// Call Close to stop the background refresh.
+func NewVerifier(ctx context.Context, optFns ...func(opts *VerifierOptions)) (*Verifier, error) {
+	opts := VerifierOptions{
+		RefreshInterval:    _defaultRefreshInterval,
+		MinRefreshInterval: _defaultMinRefreshInterval,
+		HTTPClient:         &http.Client{Timeout: _defaultJWKSTimeout},
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if len(opts.PublicKeys) == 0 && !hasJWKSSource(opts) {
+		return nil, ErrMissingKeySource
+	}
+
+	static := make(map[string]verificationKey, len(opts.PublicKeys))
+	for kid, pemKey := range opts.PublicKeys {
+		key, err := parsePublicKeyPEM([]byte(pemKey))
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key %q: %w", kid, err)
+		}
+		static[kid] = verificationKey{key: key}
+	}
+
+	v := &Verifier{
+		opts:   opts,
+		static: static,
+		stop:   make(chan struct{}),
+	}
+
+	if !hasJWKSSource(opts) {
+		return v, nil
+	}
+
+	if err := v.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if opts.RefreshInterval > 0 {
+		go v.refreshLoop()
+	}
+
+	return v, nil
+}
+
+// Close stops the background refresh of the keys.
+func (v *Verifier) Close() {
+	v.stopOnce.Do(func() {
+		close(v.stop)
+	})
+}
+
+// Refresh fetches the JWKS document and replaces the keys loaded from it.
+// Keys configured with WithPublicKeyPEM are kept.
+func (v *Verifier) Refresh(ctx context.Context) error {
+	if !hasJWKSSource(v.opts) {
+		return nil
+	}
+
+	v.refreshMu.Lock()
+	defer v.refreshMu.Unlock()
+
+	data, err := v.fetchJWKS(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.fetched = keys
+	v.lastRefresh = time.Now()
+	v.mu.Unlock()
+
+	return nil
+}
+
+// DecodeToken provides a logic to decode Pomelo token, like DecodeToken, but
+// verifying it against the keys of the Verifier.
+func (v *Verifier) DecodeToken(r *http.Request) (*Claims, error) {
+	return claimsOf(v.decode(r))
+}
+
+// Middleware behaves like the package-level Middleware, but verifies tokens
+// against the keys of the Verifier.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return decodeMiddleware(v.decode, false)(next)
+}
+
+func (v *Verifier) decode(r *http.Request) (*jwt.Token, error) {
+	return decodeToken(r, v.parse)
+}
+
+func (v *Verifier) parse(tokenHeader string) (*jwt.Token, error) {
+	return checkToken(v.verify(jwt.NewParser(), tokenHeader))
+}
+
+// verify parses and verifies tokenString with the given parser, trying every
+// candidate key. Errors are returned as reported by the parser.
+func (v *Verifier) verify(parser *jwt.Parser, tokenString string) (*jwt.Token, error) {
+	unverified, _, err := parser.ParseUnverified(tokenString, &Claims{})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := v.candidates(unverified)
+	if len(keys) == 0 {
+		return nil, errUnknownKey
+	}
+
+	var token *jwt.Token
+	for _, key := range keys {
+		token, err = parser.ParseWithClaims(tokenString, &Claims{}, staticKeyfunc(key.key))
+		// Only a bad signature is worth trying the next key.
+		if !errors.Is(err, jwt.ErrTokenSignatureInvalid) {
+			break
+		}
+	}
+
+	return token, err
+}
+
+// candidates returns the keys that may have signed the given token. Tokens
+// with an unknown kid trigger a refresh of the JWKS document.
+func (v *Verifier) candidates(token *jwt.Token) []verificationKey {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return v.compatibleKeys(token.Method)
+	}
+
+	if key, ok := v.lookup(kid); ok && key.matchesMethod(token.Method) {
+		return []verificationKey{key}
+	}
+
+	if !v.refreshOnMiss() {
+		return nil
+	}
+
+	if key, ok := v.lookup(kid); ok && key.matchesMethod(token.Method) {
+		return []verificationKey{key}
+	}
+
+	return nil
+}
+
+func (v *Verifier) lookup(kid string) (verificationKey, bool) {
+	if key, ok := v.static[kid]; ok {
+		return key, true
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	key, ok := v.fetched[kid]
+	return key, ok
+}
+
+func (v *Verifier) compatibleKeys(method jwt.SigningMethod) []verificationKey {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	kids := make([]string, 0, len(v.static)+len(v.fetched))
+	for kid := range v.static {
+		kids = append(kids, kid)
+	}
+	for kid := range v.fetched {
+		if _, ok := v.static[kid]; !ok {
+			kids = append(kids, kid)
+		}
+	}
+	slices.Sort(kids)
+
+	keys := make([]verificationKey, 0, len(kids))
+	for _, kid := range kids {
+		key, ok := v.static[kid]
+		if !ok {
+			key = v.fetched[kid]
+		}
+		if key.matchesMethod(method) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// refreshCall is a refresh triggered by an unknown key, shared by the tokens
+// that miss while it is in flight.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// refreshOnMiss fetches the JWKS document unless it was fetched, or attempted
+// to, less than MinRefreshInterval ago. Concurrent misses wait for the same
+// fetch. It reports whether the keys were refreshed.
+func (v *Verifier) refreshOnMiss() bool {
+	if !hasJWKSSource(v.opts) {
+		return false
+	}
+
+	v.mu.Lock()
+	if call := v.onMiss; call != nil {
+		v.mu.Unlock()
+		<-call.done
+		return call.err == nil
+	}
+	if time.Since(v.lastRefresh) < v.opts.MinRefreshInterval {
+		v.mu.Unlock()
+		return false
+	}
+	call := &refreshCall{done: make(chan struct{})}
+	v.onMiss = call
+	// Failed attempts count as well, so that a broken JWKS source is not
+	// fetched on every token.
+	v.lastRefresh = time.Now()
+	v.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), _defaultJWKSTimeout)
+	defer cancel()
+
+	call.err = v.Refresh(ctx)
+	if call.err != nil {
+		v.notifyRefreshError(call.err)
+	}
+
+	v.mu.Lock()
+	v.onMiss = nil
+	v.mu.Unlock()
+	close(call.done)
+
+	return call.err == nil
+}
+
+func (v *Verifier) refreshLoop() {
+	ticker := time.NewTicker(v.opts.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-v.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), _defaultJWKSTimeout)
+			if err := v.Refresh(ctx); err != nil {
+				v.notifyRefreshError(err)
+			}
+			cancel()
+		}
+	}
+}
+
+func (v *Verifier) notifyRefreshError(err error) {
+	if v.opts.RefreshErrorHandler != nil {
+		v.opts.RefreshErrorHandler(err)
+	}
+}
+
+func (v *Verifier) fetchJWKS(ctx context.Context) ([]byte, error) {
+	if v.opts.JWKSURL == "" {
+		data, err := os.ReadFile(v.opts.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading jwks: %w", err)
+		}
+		return data, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.opts.JWKSURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching jwks: %w", err)
+	}
+
+	resp, err := v.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching jwks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching jwks: unexpected status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, _maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("fetching jwks: %w", err)
+	}
+
+	return data, nil
+}
+
+func hasJWKSSource(opts VerifierOptions) bool {
+	return opts.JWKSURL != "" || opts.JWKSFile != ""
+}
+
+//revive:disable:unused-parameter
+func staticKeyfunc(key any) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		return key, nil
+	}
+}
+
+//revive:enable:unused-parameter
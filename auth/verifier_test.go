@@ -0,0 +1,396 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/auth"
+)
+
+var verifierClaims = auth.Claims{Area: []string{"issuing"}, Email: "example@pomelo.la"}
+
+func signTokenWith(t *testing.T, method jwt.SigningMethod, key crypto.PrivateKey, kid string) string {
+	t.Helper()
+
+	claims := verifierClaims
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": "P-256",
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	}
+}
+
+func marshalJWKS(t *testing.T, keys ...map[string]string) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	require.NoError(t, err)
+
+	return data
+}
+
+// jwksServer serves a JWKS document that can be replaced during the test.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	data    []byte
+	fetches int
+}
+
+func newJWKSServer(t *testing.T, data []byte) *jwksServer {
+	s := &jwksServer{data: data}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.fetches++
+		_, _ = w.Write(s.data)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *jwksServer) set(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = data
+}
+
+func (s *jwksServer) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.fetches
+}
+
+func decodeWith(v *auth.Verifier, token string) (*auth.Claims, error) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Auth-Token", token)
+
+	return v.DecodeToken(req)
+}
+
+func TestNewVerifier(t *testing.T) {
+	_, err := auth.NewVerifier(context.Background())
+	assert.ErrorIs(t, err, auth.ErrMissingKeySource)
+
+	_, err = auth.NewVerifier(context.Background(), auth.WithPublicKeyPEM("", "not a key"))
+	assert.ErrorIs(t, err, auth.ErrInvalidPublicKey)
+
+	_, err = auth.NewVerifier(context.Background(), auth.WithJWKSFile(filepath.Join(t.TempDir(), "missing.json")))
+	assert.Error(t, err)
+}
+
+func TestVerifierPublicKeyPEM(t *testing.T) {
+	v, err := auth.NewVerifier(context.Background(), auth.WithPublicKeyPEM("", TestRSAPublicKey))
+	require.NoError(t, err)
+	defer v.Close()
+
+	claims, err := decodeWith(v, signToken(t, verifierClaims))
+	require.NoError(t, err)
+	assert.Equal(t, verifierClaims.Email, claims.Email)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, err = decodeWith(v, signTokenWith(t, jwt.SigningMethodRS256, other, ""))
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+}
+
+func TestVerifierSelectsKeyByKid(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, marshalJWKS(t, rsaJWK("old", &oldKey.PublicKey), rsaJWK("new", &newKey.PublicKey)))
+
+	v, err := auth.NewVerifier(context.Background(), auth.WithJWKSURL(server.URL))
+	require.NoError(t, err)
+	defer v.Close()
+
+	_, err = decodeWith(v, signTokenWith(t, jwt.SigningMethodRS256, oldKey, "old"))
+	assert.NoError(t, err)
+
+	_, err = decodeWith(v, signTokenWith(t, jwt.SigningMethodRS256, newKey, "new"))
+	assert.NoError(t, err)
+
+	// Without kid every compatible key is tried.
+	_, err = decodeWith(v, signTokenWith(t, jwt.SigningMethodRS256, newKey, ""))
+	assert.NoError(t, err)
+
+	_, err = decodeWith(v, signTokenWith(t, jwt.SigningMethodRS256, newKey, "old"))
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+}
+
+func TestVerifierRefreshesOnUnknownKid(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, marshalJWKS(t, rsaJWK("old", &oldKey.PublicKey)))
+
+	v, err := auth.NewVerifier(context.Background(),
+		auth.WithJWKSURL(server.URL),
+		auth.WithRefreshInterval(0),
+		auth.WithMinRefreshInterval(time.Hour),
+	)
+	require.NoError(t, err)
+	defer v.Close()
+
+	server.set(marshalJWKS(t, rsaJWK("new", &newKey.PublicKey)))
+
+	// The document was fetched less than MinRefreshInterval ago.
+	_, err = decodeWith(v, signTokenWith(t, jwt.SigningMethodRS256, newKey, "new"))
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+	assert.Equal(t, 1, server.count())
+
+	v2, err := auth.NewVerifier(context.Background(),
+		auth.WithJWKSURL(server.URL),
+		auth.WithRefreshInterval(0),
+		auth.WithMinRefreshInterval(0),
+	)
+	require.NoError(t, err)
+	defer v2.Close()
+
+	server.set(marshalJWKS(t, rsaJWK("newest", &oldKey.PublicKey)))
+
+	_, err = decodeWith(v2, signTokenWith(t, jwt.SigningMethodRS256, oldKey, "newest"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, server.count())
+}
+
+func TestVerifierRefreshOnMissOnce(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, marshalJWKS(t))
+
+	v, err := auth.NewVerifier(context.Background(),
+		auth.WithJWKSURL(server.URL),
+		auth.WithRefreshInterval(0),
+		auth.WithMinRefreshInterval(50*time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer v.Close()
+
+	v2, err := auth.NewVerifier(context.Background(),
+		auth.WithJWKSURL(server.URL),
+		auth.WithRefreshInterval(0),
+		auth.WithMinRefreshInterval(0),
+	)
+	require.NoError(t, err)
+	defer v2.Close()
+	require.Equal(t, 2, server.count())
+
+	// A broken document is fetched once per MinRefreshInterval, even when the
+	// fetch fails.
+	server.set([]byte("{"))
+	time.Sleep(50 * time.Millisecond)
+	token := signTokenWith(t, jwt.SigningMethodRS256, key, "new")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := decodeWith(v, token)
+			assert.ErrorIs(t, err, auth.ErrUnauthorized)
+		}()
+	}
+	wg.Wait()
+
+	_, err = decodeWith(v, token)
+	assert.ErrorIs(t, err, auth.ErrUnauthorized)
+	assert.Equal(t, 3, server.count())
+
+	// Concurrent misses share the same fetch.
+	server.set(marshalJWKS(t, rsaJWK("new", &key.PublicKey)))
+	server.mu.Lock()
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := decodeWith(v2, token)
+			assert.NoError(t, err)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	server.mu.Unlock()
+	wg.Wait()
+
+	assert.Equal(t, 4, server.count())
+}
+
+func TestVerifierBackgroundRefresh(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	server := newJWKSServer(t, marshalJWKS(t))
+
+	var (
+		mu         sync.Mutex
+		refreshErr error
+	)
+	v, err := auth.NewVerifier(context.Background(),
+		auth.WithJWKSURL(server.URL),
+		auth.WithRefreshInterval(10*time.Millisecond),
+		auth.WithMinRefreshInterval(time.Hour),
+		auth.WithRefreshErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			refreshErr = err
+		}),
+	)
+	require.NoError(t, err)
+	defer v.Close()
+
+	server.set(marshalJWKS(t, ecJWK("ec", &key.PublicKey)))
+
+	token := signTokenWith(t, jwt.SigningMethodES256, key, "ec")
+	assert.Eventually(t, func() bool {
+		_, err := decodeWith(v, token)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	server.set([]byte("{"))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return refreshErr != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// Keys fetched before a failed refresh remain valid.
+	_, err = decodeWith(v, token)
+	assert.NoError(t, err)
+}
+
+func TestVerifierJWKSFile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+
+	rsaRS512 := rsaJWK("rsa", &rsaKey.PublicKey)
+	rsaRS512["alg"] = "RS512"
+	encryption := rsaJWK("enc", &rsaKey.PublicKey)
+	encryption["use"] = "enc"
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, marshalJWKS(t, ecJWK("ec", &key.PublicKey), rsaRS512, encryption), 0o600))
+
+	v, err := auth.NewVerifier(context.Background(), auth.WithJWKSFile(path), auth.WithRefreshInterval(0))
+	require.NoError(t, err)
+	defer v.Close()
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{
+			name:  "ecdsa key without kid",
+			token: signTokenWith(t, jwt.SigningMethodES256, key, ""),
+		},
+		{
+			name:  "rsa key restricted to another algorithm",
+			token: signTokenWith(t, jwt.SigningMethodRS256, rsaKey, "rsa"),
+			err:   auth.ErrUnauthorized,
+		},
+		{
+			name:  "rsa key with its algorithm",
+			token: signTokenWith(t, jwt.SigningMethodRS512, rsaKey, "rsa"),
+		},
+		{
+			name:  "key not meant for signatures",
+			token: signTokenWith(t, jwt.SigningMethodRS256, rsaKey, "enc"),
+			err:   auth.ErrUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := decodeWith(v, tt.token)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, verifierClaims.Area, claims.Area)
+		})
+	}
+}
+
+func TestVerifierMiddleware(t *testing.T) {
+	v, err := auth.NewVerifier(context.Background(), auth.WithPublicKeyPEM("rsa", TestRSAPublicKey))
+	require.NoError(t, err)
+	defer v.Close()
+
+	handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.ClaimsFromContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, verifierClaims.Email, claims.Email)
+	}))
+
+	rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(TestRSAPrivateKey))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Auth-Token", signTokenWith(t, jwt.SigningMethodRS256, rsaKey, "rsa"))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusNotAcceptable, res.Code)
+}
@@ -1,30 +1,64 @@
 package httprouter
 
 import (
-	"context"
 	"encoding/json"
-	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"strings"
-
-	"github.com/go-playground/validator/v10"
 )
 
 // Supported MIME Content-Types.
 const (
 	_mimeApplicationJSON = "application/json"
+	_mimeApplicationForm = "application/x-www-form-urlencoded"
+	_mimeMultipartForm   = "multipart/form-data"
 )
 
-var _validate = validator.New()
+// _multipartMaxMemory is the maximum number of bytes of a multipart body
+// stored in memory, the remainder of the file parts is stored on disk.
+const _multipartMaxMemory = 32 << 20
 
-// Bind deserializes a request body into the given destination.
+// Bind deserializes a request into the given destination.
+//
+// The type of binding of the body is dependent on the "Content-Type" for the
+// request. If the type is "application/json" it will use "json.NewDecoder",
+// while "application/x-www-form-urlencoded" and "multipart/form-data" bodies
+// are bound into the fields tagged with "form". File parts are bound into
+// fields of type *multipart.FileHeader or []*multipart.FileHeader.
+//
+// Then the fields tagged with "path", "query" and "header" are set from the
+// path parameters, query parameters and headers of the request. Values are
+// converted to strings, bools, ints, uints, floats, time.Duration, any
+// encoding.TextUnmarshaler like time.Time (RFC 3339) and slices of them,
+// which receive every value of a repeated parameter.
+//
+//	type ListCardsRequest struct {
+//		UserID string    `path:"user_id" validate:"required"`
+//		Status []string  `query:"status"`
+//		Since  time.Time `query:"since"`
+//		Tenant string    `header:"X-Tenant-Id"`
+//	}
 //
-// The type of binding is dependent on the "Content-Type" for the request.
-// If the type is "application/json" it will use "json.NewDecoder".
 // This function may invoke data validation after deserialization.
 func Bind(r *http.Request, destination any) error {
+	// A body is only required when destination can not be bound
+	// from the request parameters.
+	return bind(r, destination, !hasParamTags(destination))
+}
+
+func bind(r *http.Request, destination any, requireBody bool) error {
+	if err := bindBody(r, destination, requireBody); err != nil {
+		return err
+	}
+
+	if err := bindParams(r, destination); err != nil {
+		return err
+	}
+
+	return validateStruct(r, destination)
+}
+
+func bindBody(r *http.Request, destination any, requireBody bool) error {
 	// We default to application/json if content type is not specified but return
 	// http.StatusUnsupportedMediaType if it's specified but not supported.
 	ct := r.Header.Get("Content-Type")
@@ -34,30 +68,51 @@ func Bind(r *http.Request, destination any) error {
 
 	switch {
 	case strings.HasPrefix(ct, _mimeApplicationJSON):
-		return bindJSON(r.Context(), r.Body, destination)
+		return bindJSON(r.Body, destination, requireBody)
+	case strings.HasPrefix(ct, _mimeApplicationForm):
+		if err := r.ParseForm(); err != nil {
+			if tooLarge, ok := bodyTooLarge(err); ok {
+				return tooLarge
+			}
+			return NewErrorf(http.StatusBadRequest, "invalid form body: %v", err)
+		}
+		return nil
+	case strings.HasPrefix(ct, _mimeMultipartForm):
+		if err := r.ParseMultipartForm(_multipartMaxMemory); err != nil {
+			if tooLarge, ok := bodyTooLarge(err); ok {
+				return tooLarge
+			}
+			return NewErrorf(http.StatusBadRequest, "invalid multipart body: %v", err)
+		}
+		return nil
 	default:
 		return NewErrorf(http.StatusUnsupportedMediaType, "unsupported media type: %s", ct)
 	}
 }
 
-func bindJSON(ctx context.Context, r io.Reader, destination any) error {
-	b, err := io.ReadAll(r)
-	if err != nil {
-		return err
+func bindJSON(r io.Reader, destination any, requireBody bool) error {
+	var b []byte
+	if r != nil {
+		var err error
+		if b, err = io.ReadAll(r); err != nil {
+			if tooLarge, ok := bodyTooLarge(err); ok {
+				return tooLarge
+			}
+			return err
+		}
 	}
 
 	// In order to detect empty request body, we check for len(b) to be zero.
 	// ReadAll is defined to read from src until EOF, and it does not
 	// treat it as en error as it happens when using json.Decoder.
 	if len(b) == 0 {
+		if !requireBody {
+			return nil
+		}
 		return NewErrorf(http.StatusBadRequest, "Request body is empty")
 	}
 
-	if err := unmarshal(b, destination); err != nil {
-		return err
-	}
-
-	return validateStruct(ctx, destination)
+	return unmarshal(b, destination)
 }
 
 func unmarshal(b []byte, destination any) error {
@@ -76,29 +131,3 @@ func unmarshal(b []byte, destination any) error {
 
 	return nil
 }
-
-func validateStruct(ctx context.Context, destination any) error {
-	if err := _validate.StructCtx(ctx, destination); err != nil {
-		var invalidValidationError *validator.InvalidValidationError
-		if errors.As(err, &invalidValidationError) {
-			// We choose to ignore errors related to types
-			// that can't be validated like time.Time and slices.
-			return nil
-		}
-
-		message := err.Error()
-
-		var validationErrs validator.ValidationErrors
-		if errors.As(err, &validationErrs) {
-			fields := make([]string, 0, len(validationErrs))
-			for _, v := range validationErrs {
-				fields = append(fields, v.Field())
-			}
-			message = fmt.Sprintf("invalid fields: %s", strings.Join(fields, ","))
-		}
-
-		return NewErrorf(http.StatusUnprocessableEntity, "validation_error: %s", message)
-	}
-
-	return nil
-}
@@ -0,0 +1,256 @@
+package httprouter
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Struct tags naming the request parameter a field is bound from.
+const (
+	_tagPath   = "path"
+	_tagQuery  = "query"
+	_tagHeader = "header"
+	_tagForm   = "form"
+)
+
+var _paramTags = []string{_tagPath, _tagQuery, _tagHeader, _tagForm}
+
+var (
+	_durationType        = reflect.TypeOf(time.Duration(0))
+	_fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	_fileHeadersType     = reflect.TypeOf([]*multipart.FileHeader(nil))
+	_textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+var errUnsupportedField = errors.New("unsupported field type")
+
+// bindParams binds the path parameters, query parameters, headers and form
+// values of the request into the fields of destination tagged with "path",
+// "query", "header" and "form" respectively. Parameters missing from the
+// request leave the field untouched.
+func bindParams(r *http.Request, destination any) error {
+	v, ok := structValue(destination)
+	if !ok {
+		return nil
+	}
+
+	return bindStructParams(r, v)
+}
+
+// hasParamTags reports whether destination has any field bound from the
+// request parameters.
+func hasParamTags(destination any) bool {
+	v, ok := structValue(destination)
+	if !ok {
+		return false
+	}
+
+	return structHasParamTags(v.Type())
+}
+
+func structValue(destination any) (reflect.Value, bool) {
+	v := reflect.ValueOf(destination)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return reflect.Value{}, false
+	}
+
+	v = v.Elem()
+	return v, v.Kind() == reflect.Struct
+}
+
+func structHasParamTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && structHasParamTags(field.Type) {
+			return true
+		}
+
+		for _, tag := range _paramTags {
+			if _, tagged := field.Tag.Lookup(tag); tagged {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func bindStructParams(r *http.Request, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := v.Field(i)
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			if err := bindStructParams(r, fv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if name, tagged := field.Tag.Lookup(_tagForm); tagged && isFileField(fv.Type()) {
+			bindFiles(r, fv, name)
+			continue
+		}
+
+		source, name, values, ok := paramValues(r, field)
+		if !ok {
+			continue
+		}
+
+		err := setField(fv, values)
+		if errors.Is(err, errUnsupportedField) {
+			return fmt.Errorf("binding %s parameter %s into field %s: %w", source, name, field.Name, err)
+		}
+		if err != nil {
+			return NewErrorf(http.StatusBadRequest, "invalid %s parameter %s: %q", source, name, strings.Join(values, ","))
+		}
+	}
+
+	return nil
+}
+
+// paramValues returns the values of the request parameter the field is bound
+// from, if the field is tagged and the parameter is present.
+func paramValues(r *http.Request, field reflect.StructField) (source, name string, values []string, ok bool) {
+	if name, tagged := field.Tag.Lookup(_tagPath); tagged {
+		value := chi.URLParam(r, name)
+		return _tagPath, name, []string{value}, value != ""
+	}
+
+	if name, tagged := field.Tag.Lookup(_tagQuery); tagged {
+		if r.URL == nil {
+			return _tagQuery, name, nil, false
+		}
+		values := r.URL.Query()[name]
+		return _tagQuery, name, values, len(values) > 0
+	}
+
+	if name, tagged := field.Tag.Lookup(_tagHeader); tagged {
+		values := r.Header.Values(name)
+		return _tagHeader, name, values, len(values) > 0
+	}
+
+	if name, tagged := field.Tag.Lookup(_tagForm); tagged {
+		values := r.PostForm[name]
+		return _tagForm, name, values, len(values) > 0
+	}
+
+	return "", "", nil, false
+}
+
+func isFileField(t reflect.Type) bool {
+	return t == _fileHeaderType || t == _fileHeadersType
+}
+
+// bindFiles sets the file parts of a multipart request named name into fv.
+func bindFiles(r *http.Request, fv reflect.Value, name string) {
+	if r.MultipartForm == nil || len(r.MultipartForm.File[name]) == 0 {
+		return
+	}
+
+	files := r.MultipartForm.File[name]
+	if fv.Type() == _fileHeaderType {
+		fv.Set(reflect.ValueOf(files[0]))
+		return
+	}
+	fv.Set(reflect.ValueOf(files))
+}
+
+// setField converts values to the type of the field and sets it. Slice fields
+// receive every value, any other field receives the first one.
+func setField(fv reflect.Value, values []string) error {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+
+	if fv.Kind() == reflect.Slice && !isTextUnmarshaler(fv) {
+		slice := reflect.MakeSlice(fv.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		fv.Set(slice)
+		return nil
+	}
+
+	return setValue(fv, values[0])
+}
+
+// setValue converts value to the type of fv and sets it.
+func setValue(fv reflect.Value, value string) error {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+
+	if isTextUnmarshaler(fv) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	if fv.Type() == _durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return errUnsupportedField
+	}
+
+	return nil
+}
+
+// isTextUnmarshaler reports whether fv implements encoding.TextUnmarshaler,
+// like time.Time does with RFC 3339 values.
+func isTextUnmarshaler(fv reflect.Value) bool {
+	return fv.CanAddr() && fv.Addr().Type().Implements(_textUnmarshalerType)
+}
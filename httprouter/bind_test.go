@@ -1,12 +1,17 @@
 package httprouter_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"io"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/require"
 
 	"github.com/pomelo-la/go-toolkit/httprouter"
@@ -136,3 +141,189 @@ func TestBind_UnsupportedMediaType(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, http.StatusUnsupportedMediaType, webErr.StatusCode)
 }
+
+func TestBind_Params(t *testing.T) {
+	type listCards struct {
+		UserID  string        `path:"user_id" validate:"required"`
+		Page    int           `query:"page"`
+		Active  *bool         `query:"active"`
+		Status  []string      `query:"status"`
+		Amounts []float64     `query:"amount"`
+		Since   time.Time     `query:"since"`
+		Timeout time.Duration `query:"timeout"`
+		Tenant  string        `header:"X-Tenant-Id"`
+	}
+
+	type unsupported struct {
+		Filter map[string]string `query:"filter"`
+	}
+
+	active := true
+	since := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tt := []struct {
+		name               string
+		target             string
+		destination        any
+		expected           any
+		expectedErr        string
+		expectedStatusCode int
+	}{
+		{
+			name:        "should bind path, query and headers without body",
+			target:      "/users/usr-1/cards?page=2&active=true&status=active&status=blocked&amount=1.5&since=2024-03-01T10:00:00Z&timeout=1m30s",
+			destination: &listCards{},
+			expected: &listCards{
+				UserID:  "usr-1",
+				Page:    2,
+				Active:  &active,
+				Status:  []string{"active", "blocked"},
+				Amounts: []float64{1.5},
+				Since:   since,
+				Timeout: 90 * time.Second,
+				Tenant:  "tnt-1",
+			},
+		},
+		{
+			name:               "should return bad request when value can not be converted",
+			target:             "/users/usr-1/cards?page=two",
+			destination:        &listCards{},
+			expectedErr:        `400 bad_request: invalid query parameter page: "two"`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "should return bad request when time is malformed",
+			target:             "/users/usr-1/cards?since=yesterday",
+			destination:        &listCards{},
+			expectedErr:        `400 bad_request: invalid query parameter since: "yesterday"`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:        "should return error when field type is not supported",
+			target:      "/users/usr-1/cards?filter=a",
+			destination: &unsupported{},
+			expectedErr: "binding query parameter filter into field Filter: unsupported field type",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var err error
+			r := chi.NewRouter()
+			r.Get("/users/{user_id}/cards", func(w http.ResponseWriter, r *http.Request) {
+				err = httprouter.Bind(r, tc.destination)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			req.Header.Set("X-Tenant-Id", "tnt-1")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				if tc.expectedStatusCode != 0 {
+					var webErr *httprouter.Error
+					require.ErrorAs(t, err, &webErr)
+					require.Equal(t, tc.expectedStatusCode, webErr.StatusCode)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, tc.destination)
+		})
+	}
+}
+
+func TestBind_Form(t *testing.T) {
+	type signup struct {
+		Email    string   `form:"email" validate:"required,email"`
+		Age      uint8    `form:"age"`
+		Interest []string `form:"interest"`
+	}
+
+	tt := []struct {
+		name               string
+		body               string
+		expected           signup
+		expectedErr        string
+		expectedStatusCode int
+	}{
+		{
+			name:     "should bind form values",
+			body:     "email=jane%40pomelo.la&age=30&interest=cards&interest=loans",
+			expected: signup{Email: "jane@pomelo.la", Age: 30, Interest: []string{"cards", "loans"}},
+		},
+		{
+			name:               "should validate form values",
+			body:               "email=jane",
+			expectedErr:        "422 unprocessable_entity: validation_error: invalid fields: Email",
+			expectedStatusCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:               "should return bad request when value overflows",
+			body:               "email=jane%40pomelo.la&age=300",
+			expectedErr:        `400 bad_request: invalid form parameter age: "300"`,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			var destination signup
+			err := httprouter.Bind(req, &destination)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				webErr := err.(*httprouter.Error)
+				require.Equal(t, tc.expectedStatusCode, webErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, destination)
+		})
+	}
+}
+
+func TestBind_Multipart(t *testing.T) {
+	type upload struct {
+		Description string                  `form:"description" validate:"required"`
+		Document    *multipart.FileHeader   `form:"document" validate:"required"`
+		Attachments []*multipart.FileHeader `form:"attachment"`
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("description", "proof of address"))
+	for field, name := range map[string]string{"document": "dni.pdf", "attachment": "bill.pdf"} {
+		fw, err := mw.CreateFormFile(field, name)
+		require.NoError(t, err)
+		_, err = fw.Write([]byte(name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/documents", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var destination upload
+	require.NoError(t, httprouter.Bind(req, &destination))
+	require.Equal(t, "proof of address", destination.Description)
+	require.Equal(t, "dni.pdf", destination.Document.Filename)
+	require.Len(t, destination.Attachments, 1)
+	require.Equal(t, "bill.pdf", destination.Attachments[0].Filename)
+
+	f, err := destination.Document.Open()
+	require.NoError(t, err)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "dni.pdf", string(content))
+
+	req = httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader("--"))
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=missing")
+	err = httprouter.Bind(req, &destination)
+	webErr, ok := err.(*httprouter.Error)
+	require.True(t, ok)
+	require.Equal(t, http.StatusBadRequest, webErr.StatusCode)
+}
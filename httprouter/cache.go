@@ -0,0 +1,154 @@
+package httprouter
+
+import (
+	"container/list"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheControl represents the caching policy of the responses of a route, as
+// sent in the "Cache-Control" header defined by RFC 9111.
+type CacheControl struct {
+	// MaxAge is for how long the response is fresh.
+	MaxAge time.Duration
+	// SharedMaxAge is for how long the response is fresh in shared caches,
+	// like proxies and CDNs, overriding MaxAge.
+	SharedMaxAge time.Duration
+	// StaleWhileRevalidate is for how long a stale response may be used while
+	// it is revalidated in the background, as defined by RFC 5861.
+	StaleWhileRevalidate time.Duration
+	// Public allows shared caches to store the response.
+	Public bool
+	// Private forbids shared caches to store the response.
+	Private bool
+	// NoCache requires caches to revalidate the response before using it.
+	NoCache bool
+	// NoStore forbids caches to store the response.
+	NoStore bool
+	// MustRevalidate forbids caches to use the response once stale.
+	MustRevalidate bool
+	// Immutable states that the response never changes while fresh.
+	Immutable bool
+}
+
+// String formats the policy as the value of the "Cache-Control" header.
+func (c CacheControl) String() string {
+	var directives []string
+	if c.Public {
+		directives = append(directives, "public")
+	}
+	if c.Private {
+		directives = append(directives, "private")
+	}
+	if c.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if c.NoStore {
+		directives = append(directives, "no-store")
+	}
+	if c.MaxAge > 0 {
+		directives = append(directives, "max-age="+strconv.Itoa(seconds(c.MaxAge)))
+	}
+	if c.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+strconv.Itoa(seconds(c.SharedMaxAge)))
+	}
+	if c.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+strconv.Itoa(seconds(c.StaleWhileRevalidate)))
+	}
+	if c.MustRevalidate {
+		directives = append(directives, "must-revalidate")
+	}
+	if c.Immutable {
+		directives = append(directives, "immutable")
+	}
+
+	return strings.Join(directives, ", ")
+}
+
+// ResponseCache is a bounded in-process cache of responses, which evicts the
+// least recently used response once full. A ResponseCache can be shared by
+// the Cache middlewares of several routes.
+type ResponseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+// cachedResponse is a response stored in a ResponseCache.
+type cachedResponse struct {
+	key        string
+	statusCode int
+	header     http.Header
+	body       []byte
+	expiresAt  time.Time
+}
+
+// NewResponseCache instantiates an empty ResponseCache holding up to
+// maxEntries responses.
+func NewResponseCache(maxEntries int) *ResponseCache {
+	return &ResponseCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Len returns the number of responses in the cache.
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.lru.Len()
+}
+
+// Purge removes every response from the cache.
+func (c *ResponseCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+func (c *ResponseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	res := elem.Value.(*cachedResponse)
+	if time.Now().After(res.expiresAt) {
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return res, true
+}
+
+func (c *ResponseCache) set(res *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[res.key]; ok {
+		elem.Value = res
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[res.key] = c.lru.PushFront(res)
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
@@ -0,0 +1,47 @@
+package httprouter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func TestCacheControl_String(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy httprouter.CacheControl
+		want   string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:   "public",
+			policy: httprouter.CacheControl{Public: true, MaxAge: time.Minute, SharedMaxAge: time.Hour, StaleWhileRevalidate: 30 * time.Second},
+			want:   "public, max-age=60, s-maxage=3600, stale-while-revalidate=30",
+		},
+		{
+			name:   "private",
+			policy: httprouter.CacheControl{Private: true, NoCache: true, MustRevalidate: true},
+			want:   "private, no-cache, must-revalidate",
+		},
+		{
+			name:   "immutable",
+			policy: httprouter.CacheControl{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true},
+			want:   "public, max-age=31536000, immutable",
+		},
+		{
+			name:   "no store",
+			policy: httprouter.CacheControl{NoStore: true},
+			want:   "no-store",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.policy.String())
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package httprouter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	_instrumentationName = "github.com/pomelo-la/go-toolkit/httprouter"
+
+	_defaultBreakerWindow         = 60 * time.Second
+	_defaultBreakerBuckets        = 10
+	_defaultBreakerFailureRatio   = 0.5
+	_defaultBreakerMinRequests    = 20
+	_defaultBreakerCooldown       = 30 * time.Second
+	_defaultBreakerHalfOpenProbes = 1
+)
+
+// BreakerState represents the state of a RollingBreaker.
+type BreakerState int
+
+const (
+	// StateClosed lets every request through while tracking its outcome.
+	StateClosed BreakerState = iota
+	// StateOpen rejects every request until the cooldown elapses.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe requests through in order
+	// to decide whether the circuit can be closed again.
+	StateHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half_open"
+	default:
+		return "unknown"
+	}
+}
+
+// RollingBreakerOptions represents the options for configuring a RollingBreaker.
+type RollingBreakerOptions struct {
+	// Window is the length of the rolling window used to compute the failure ratio.
+	Window time.Duration
+	// Buckets is the number of buckets in which the window is divided.
+	Buckets int
+	// FailureRatio is the ratio of failures within the window that opens the circuit.
+	FailureRatio float64
+	// MinRequests is the minimum number of requests within the window
+	// before the failure ratio is taken into account.
+	MinRequests int
+	// ConsecutiveFailures is the number of consecutive failures that opens
+	// the circuit, zero disables this threshold.
+	ConsecutiveFailures int
+	// Cooldown is the time the circuit remains open before letting probes
+	// through, and the time the probes have to report their outcome before
+	// the circuit opens again.
+	Cooldown time.Duration
+	// HalfOpenProbes is the number of probe requests allowed in half-open
+	// state, all of them must succeed in order to close the circuit.
+	HalfOpenProbes int
+	// MeterProvider is used to export the state of the circuit.
+	MeterProvider metric.MeterProvider
+}
+
+// WithBreakerWindow allows you to configure the rolling window used to compute
+// the failure ratio and the number of buckets in which it is divided.
+//
+// Default behavior is to use a window of 60 seconds divided in 10 buckets.
+func WithBreakerWindow(window time.Duration, buckets int) func(opts *RollingBreakerOptions) {
+	return func(opts *RollingBreakerOptions) {
+		opts.Window = window
+		opts.Buckets = buckets
+	}
+}
+
+// WithBreakerFailureRatio allows you to configure the ratio of failures that
+// opens the circuit once at least minRequests were observed within the window.
+//
+// Default behavior is to open the circuit when half of at least 20 requests failed.
+func WithBreakerFailureRatio(ratio float64, minRequests int) func(opts *RollingBreakerOptions) {
+	return func(opts *RollingBreakerOptions) {
+		opts.FailureRatio = ratio
+		opts.MinRequests = minRequests
+	}
+}
+
+// WithBreakerConsecutiveFailures allows you to configure the number of
+// consecutive failures that opens the circuit regardless of the failure ratio.
+//
+// Default behavior is to only consider the failure ratio.
+func WithBreakerConsecutiveFailures(failures int) func(opts *RollingBreakerOptions) {
+	return func(opts *RollingBreakerOptions) {
+		opts.ConsecutiveFailures = failures
+	}
+}
+
+// WithBreakerCooldown allows you to configure the time the circuit remains
+// open before letting probe requests through. Probes that do not report
+// their outcome within the cooldown are considered failed.
+//
+// Default behavior is to use a cooldown of 30 seconds.
+func WithBreakerCooldown(cooldown time.Duration) func(opts *RollingBreakerOptions) {
+	return func(opts *RollingBreakerOptions) {
+		opts.Cooldown = cooldown
+	}
+}
+
+// WithBreakerHalfOpenProbes allows you to configure the number of probe
+// requests allowed in half-open state.
+//
+// Default behavior is to allow a single probe.
+func WithBreakerHalfOpenProbes(probes int) func(opts *RollingBreakerOptions) {
+	return func(opts *RollingBreakerOptions) {
+		opts.HalfOpenProbes = probes
+	}
+}
+
+// WithBreakerMeterProvider allows you to configure the metric.MeterProvider
+// used to export the state of the circuit.
+//
+// Default behavior is to use the global meter provider.
+func WithBreakerMeterProvider(provider metric.MeterProvider) func(opts *RollingBreakerOptions) {
+	return func(opts *RollingBreakerOptions) {
+		opts.MeterProvider = provider
+	}
+}
+
+// RollingBreaker is a CircuitBreaker that opens the circuit based on the
+// ratio of failures observed within a rolling window or on a number of
+// consecutive failures.
+//
+// It doesn't depend on any server type, so it can be used both with the
+// Breaker middleware and to protect outgoing requests.
+//
+// Every state transition is counted in the circuit_breaker.transition.counter
+// metric and the current state is exported in the circuit_breaker.state gauge
+// (0 closed, 1 open, 2 half-open).
+type RollingBreaker struct {
+	name        string
+	opts        RollingBreakerOptions
+	transitions metric.Int64Counter
+
+	mu          sync.Mutex
+	state       BreakerState
+	changedAt   time.Time
+	consecutive int
+	probes      int
+	successes   int
+	window      rollingWindow
+}
+
+// NewRollingBreaker instantiates a RollingBreaker with sane defaults. The given
+// name identifies the circuit in the exported metrics.
+func NewRollingBreaker(name string, optFns ...func(opts *RollingBreakerOptions)) (*RollingBreaker, error) {
+	opts := RollingBreakerOptions{
+		Window:         _defaultBreakerWindow,
+		Buckets:        _defaultBreakerBuckets,
+		FailureRatio:   _defaultBreakerFailureRatio,
+		MinRequests:    _defaultBreakerMinRequests,
+		Cooldown:       _defaultBreakerCooldown,
+		HalfOpenProbes: _defaultBreakerHalfOpenProbes,
+		MeterProvider:  otel.GetMeterProvider(),
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Buckets <= 0 {
+		opts.Buckets = 1
+	}
+	if opts.HalfOpenProbes <= 0 {
+		opts.HalfOpenProbes = 1
+	}
+
+	meter := opts.MeterProvider.Meter(_instrumentationName)
+
+	transitions, err := meter.Int64Counter("circuit_breaker.transition.counter")
+	if err != nil {
+		return nil, err
+	}
+
+	cb := &RollingBreaker{
+		name:        name,
+		opts:        opts,
+		transitions: transitions,
+		window:      newRollingWindow(opts.Window, opts.Buckets),
+	}
+
+	_, err = meter.Int64ObservableGauge("circuit_breaker.state",
+		metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+			observer.Observe(int64(cb.State()), metric.WithAttributes(attribute.String("name", name)))
+			return nil
+		}))
+	if err != nil {
+		return nil, err
+	}
+
+	return cb, nil
+}
+
+// State returns the current state of the circuit.
+func (cb *RollingBreaker) State() BreakerState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.state
+}
+
+// Allow checks if a request is allowed to proceed.
+func (cb *RollingBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(cb.changedAt)
+	switch {
+	case cb.state == StateOpen && elapsed >= cb.opts.Cooldown:
+		cb.transition(StateHalfOpen, now)
+	case cb.state == StateHalfOpen && cb.probes >= cb.opts.HalfOpenProbes && elapsed >= cb.opts.Cooldown:
+		// The probes never reported back, consider them failed.
+		cb.transition(StateOpen, now)
+	}
+
+	switch cb.state {
+	case StateClosed:
+		return true
+	case StateHalfOpen:
+		if cb.probes >= cb.opts.HalfOpenProbes {
+			return false
+		}
+		cb.probes++
+		return true
+	default:
+		return false
+	}
+}
+
+// Success signals a successful request to the circuit breaker.
+func (cb *RollingBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	switch cb.state {
+	case StateClosed:
+		cb.consecutive = 0
+		cb.window.record(now, true)
+	case StateHalfOpen:
+		cb.successes++
+		if cb.successes >= cb.opts.HalfOpenProbes {
+			cb.transition(StateClosed, now)
+		}
+	}
+}
+
+// Failure signals a failed request to the circuit breaker.
+func (cb *RollingBreaker) Failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	switch cb.state {
+	case StateClosed:
+		cb.consecutive++
+		cb.window.record(now, false)
+		if cb.shouldOpen(now) {
+			cb.transition(StateOpen, now)
+		}
+	case StateHalfOpen:
+		cb.transition(StateOpen, now)
+	}
+}
+
+func (cb *RollingBreaker) shouldOpen(now time.Time) bool {
+	if cb.opts.ConsecutiveFailures > 0 && cb.consecutive >= cb.opts.ConsecutiveFailures {
+		return true
+	}
+
+	successes, failures := cb.window.totals(now)
+	total := successes + failures
+	if total == 0 || total < cb.opts.MinRequests {
+		return false
+	}
+
+	return float64(failures)/float64(total) >= cb.opts.FailureRatio
+}
+
+// transition moves the circuit to the given state resetting its counters.
+// It must be called with the lock held.
+func (cb *RollingBreaker) transition(to BreakerState, now time.Time) {
+	from := cb.state
+	cb.state = to
+	cb.consecutive = 0
+	cb.probes = 0
+	cb.successes = 0
+	cb.window.reset()
+	cb.changedAt = now
+
+	cb.transitions.Add(context.Background(), 1, metric.WithAttributes(
+		attribute.String("name", cb.name),
+		attribute.String("from", from.String()),
+		attribute.String("to", to.String()),
+	))
+}
+
+// rollingWindow counts outcomes in a ring of buckets, each of them covering
+// a fixed slice of time.
+type rollingWindow struct {
+	size    int64
+	buckets []bucket
+}
+
+type bucket struct {
+	epoch     int64
+	successes int
+	failures  int
+}
+
+func newRollingWindow(window time.Duration, buckets int) rollingWindow {
+	size := int64(window) / int64(buckets)
+	if size <= 0 {
+		size = 1
+	}
+
+	return rollingWindow{
+		size:    size,
+		buckets: make([]bucket, buckets),
+	}
+}
+
+func (w *rollingWindow) record(now time.Time, success bool) {
+	epoch := now.UnixNano() / w.size
+	b := &w.buckets[epoch%int64(len(w.buckets))]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+
+	if success {
+		b.successes++
+	} else {
+		b.failures++
+	}
+}
+
+func (w *rollingWindow) totals(now time.Time) (successes, failures int) {
+	epoch := now.UnixNano() / w.size
+	oldest := epoch - int64(len(w.buckets))
+	for _, b := range w.buckets {
+		if b.epoch > oldest && b.epoch <= epoch {
+			successes += b.successes
+			failures += b.failures
+		}
+	}
+
+	return successes, failures
+}
+
+func (w *rollingWindow) reset() {
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+}
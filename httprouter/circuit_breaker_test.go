@@ -0,0 +1,185 @@
+package httprouter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func TestRollingBreaker(t *testing.T) {
+	tests := []struct {
+		name      string
+		optFns    []func(opts *httprouter.RollingBreakerOptions)
+		outcomes  []bool
+		wantState httprouter.BreakerState
+	}{
+		{
+			name:      "stays closed below min requests",
+			optFns:    []func(opts *httprouter.RollingBreakerOptions){httprouter.WithBreakerFailureRatio(0.5, 4)},
+			outcomes:  []bool{false, false, false},
+			wantState: httprouter.StateClosed,
+		},
+		{
+			name:      "opens when failure ratio is reached",
+			optFns:    []func(opts *httprouter.RollingBreakerOptions){httprouter.WithBreakerFailureRatio(0.5, 4)},
+			outcomes:  []bool{true, false, true, false},
+			wantState: httprouter.StateOpen,
+		},
+		{
+			name:      "stays closed below failure ratio",
+			optFns:    []func(opts *httprouter.RollingBreakerOptions){httprouter.WithBreakerFailureRatio(0.5, 4)},
+			outcomes:  []bool{true, true, true, false},
+			wantState: httprouter.StateClosed,
+		},
+		{
+			name:      "opens on consecutive failures",
+			optFns:    []func(opts *httprouter.RollingBreakerOptions){httprouter.WithBreakerConsecutiveFailures(3)},
+			outcomes:  []bool{true, false, false, false},
+			wantState: httprouter.StateOpen,
+		},
+		{
+			name:      "success resets consecutive failures",
+			optFns:    []func(opts *httprouter.RollingBreakerOptions){httprouter.WithBreakerConsecutiveFailures(3)},
+			outcomes:  []bool{false, false, true, false, false},
+			wantState: httprouter.StateClosed,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cb, err := httprouter.NewRollingBreaker("test", tc.optFns...)
+			require.NoError(t, err)
+
+			for _, success := range tc.outcomes {
+				require.True(t, cb.Allow())
+				if success {
+					cb.Success()
+				} else {
+					cb.Failure()
+				}
+			}
+
+			assert.Equal(t, tc.wantState, cb.State())
+			assert.Equal(t, tc.wantState == httprouter.StateClosed, cb.Allow())
+		})
+	}
+}
+
+func TestRollingBreaker_HalfOpen(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	cb, err := httprouter.NewRollingBreaker("half-open",
+		httprouter.WithBreakerConsecutiveFailures(1),
+		httprouter.WithBreakerCooldown(20*time.Millisecond),
+		httprouter.WithBreakerHalfOpenProbes(2),
+		httprouter.WithBreakerMeterProvider(mp),
+	)
+	require.NoError(t, err)
+
+	require.True(t, cb.Allow())
+	cb.Failure()
+	require.Equal(t, httprouter.StateOpen, cb.State())
+	require.False(t, cb.Allow())
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Only the probe budget is let through.
+	assert.True(t, cb.Allow())
+	assert.True(t, cb.Allow())
+	assert.False(t, cb.Allow())
+	assert.Equal(t, httprouter.StateHalfOpen, cb.State())
+
+	// A failed probe opens the circuit again.
+	cb.Failure()
+	assert.Equal(t, httprouter.StateOpen, cb.State())
+
+	time.Sleep(30 * time.Millisecond)
+
+	assert.True(t, cb.Allow())
+	assert.True(t, cb.Allow())
+	cb.Success()
+	assert.Equal(t, httprouter.StateHalfOpen, cb.State())
+	cb.Success()
+	assert.Equal(t, httprouter.StateClosed, cb.State())
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	transitions := metrics["circuit_breaker.transition.counter"].(metricdata.Sum[int64])
+	var total int64
+	for _, dp := range transitions.DataPoints {
+		total += dp.Value
+	}
+	assert.EqualValues(t, 5, total)
+
+	state := metrics["circuit_breaker.state"].(metricdata.Gauge[int64])
+	require.Len(t, state.DataPoints, 1)
+	assert.EqualValues(t, httprouter.StateClosed, state.DataPoints[0].Value)
+}
+
+func TestRollingBreaker_LostProbe(t *testing.T) {
+	cb, err := httprouter.NewRollingBreaker("lost-probe",
+		httprouter.WithBreakerConsecutiveFailures(1),
+		httprouter.WithBreakerCooldown(20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	require.True(t, cb.Allow())
+	cb.Failure()
+
+	time.Sleep(30 * time.Millisecond)
+
+	// The probe never reports its outcome.
+	require.True(t, cb.Allow())
+	require.False(t, cb.Allow())
+
+	time.Sleep(30 * time.Millisecond)
+
+	assert.False(t, cb.Allow())
+	assert.Equal(t, httprouter.StateOpen, cb.State())
+
+	time.Sleep(30 * time.Millisecond)
+
+	assert.True(t, cb.Allow())
+	cb.Success()
+	assert.Equal(t, httprouter.StateClosed, cb.State())
+}
+
+func TestRollingBreaker_Middleware(t *testing.T) {
+	cb, err := httprouter.NewRollingBreaker("middleware", httprouter.WithBreakerConsecutiveFailures(2))
+	require.NoError(t, err)
+
+	app := httprouter.New()
+	app.Use(httprouter.Breaker(cb, httprouter.DefaultBreakerValidator))
+	app.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.NewError(http.StatusInternalServerError, "boom")
+	})
+
+	codes := make([]int, 0, 3)
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		codes = append(codes, recorder.Code)
+	}
+
+	assert.Equal(t, []int{
+		http.StatusInternalServerError,
+		http.StatusInternalServerError,
+		http.StatusServiceUnavailable,
+	}, codes)
+}
@@ -0,0 +1,322 @@
+package httprouter
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+// Supported response MIME Content-Types.
+const (
+	_mimeApplicationXML      = "application/xml"
+	_mimeApplicationProtobuf = "application/x-protobuf"
+	_mimeApplicationMsgpack  = "application/msgpack"
+	_mimeTextPlain           = "text/plain; charset=utf-8"
+	_mimeTextCSV             = "text/csv; charset=utf-8"
+)
+
+// ErrUnsupportedValue is returned by an Encoder when it can not encode a value,
+// in which case Respond tries the next encoder accepted by the client.
+var ErrUnsupportedValue = errors.New("unsupported value")
+
+// Encoder encodes the values sent to the client by Respond.
+type Encoder interface {
+	Encode(w io.Writer, value any) error
+}
+
+// EncoderFunc is an adapter to allow the use of ordinary functions as Encoder.
+type EncoderFunc func(w io.Writer, value any) error
+
+// Encode calls f(w, value).
+func (f EncoderFunc) Encode(w io.Writer, value any) error {
+	return f(w, value)
+}
+
+// CSVMarshaler is implemented by values that encode themselves as CSV records.
+type CSVMarshaler interface {
+	MarshalCSV() ([][]string, error)
+}
+
+type registeredEncoder struct {
+	contentType string
+	mediaType   string
+	encoder     Encoder
+}
+
+var (
+	_encodersMu sync.RWMutex
+	_encoders   = []registeredEncoder{
+		newRegisteredEncoder(_mimeApplicationJSON, EncoderFunc(encodeJSON)),
+		newRegisteredEncoder(_mimeApplicationXML, EncoderFunc(encodeXML)),
+		newRegisteredEncoder(_mimeApplicationProtobuf, EncoderFunc(encodeProtobuf)),
+		newRegisteredEncoder(_mimeApplicationMsgpack, EncoderFunc(encodeMsgpack)),
+		newRegisteredEncoder(_mimeTextPlain, EncoderFunc(encodeText)),
+		newRegisteredEncoder(_mimeTextCSV, EncoderFunc(encodeCSV)),
+	}
+)
+
+// RegisterEncoder registers the Encoder used by Respond for the given
+// Content-Type, replacing the one already registered for its media type.
+// JSON, XML, protobuf, MessagePack, plain text and CSV are registered by
+// default, JSON being used when the client accepts any media type.
+//
+//	httprouter.RegisterEncoder("application/yaml", httprouter.EncoderFunc(func(w io.Writer, v any) error {
+//		return yaml.NewEncoder(w).Encode(v)
+//	}))
+//
+// It is not safe for concurrent use, register encoders before serving requests.
+func RegisterEncoder(contentType string, encoder Encoder) {
+	enc := newRegisteredEncoder(contentType, encoder)
+
+	_encodersMu.Lock()
+	defer _encodersMu.Unlock()
+
+	for i, e := range _encoders {
+		if e.mediaType == enc.mediaType {
+			_encoders[i] = enc
+			return
+		}
+	}
+	_encoders = append(_encoders, enc)
+}
+
+func newRegisteredEncoder(contentType string, encoder Encoder) registeredEncoder {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(contentType)
+	}
+
+	return registeredEncoder{contentType: contentType, mediaType: mediaType, encoder: encoder}
+}
+
+// negotiate returns the registered encoders accepted by the given "Accept"
+// header, in order of preference.
+func negotiate(accept string) []registeredEncoder {
+	_encodersMu.RLock()
+	defer _encodersMu.RUnlock()
+
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return append([]registeredEncoder(nil), _encoders...)
+	}
+
+	type candidate struct {
+		encoder registeredEncoder
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, enc := range _encoders {
+		if q := quality(ranges, enc.mediaType); q > 0 {
+			candidates = append(candidates, candidate{encoder: enc, quality: q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	encoders := make([]registeredEncoder, 0, len(candidates))
+	for _, c := range candidates {
+		encoders = append(encoders, c.encoder)
+	}
+
+	return encoders
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: q})
+	}
+
+	return ranges
+}
+
+// quality returns the quality of the most specific media range that matches
+// mediaType.
+func quality(ranges []mediaRange, mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch r.mediaType {
+		case mediaType:
+			s = 2
+		case typ + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		}
+
+		if s > specificity {
+			q, specificity = r.quality, s
+		}
+	}
+
+	return q
+}
+
+func encodeJSON(w io.Writer, value any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func encodeXML(w io.Writer, value any) error {
+	err := xml.NewEncoder(w).Encode(value)
+
+	var unsupportedType *xml.UnsupportedTypeError
+	if errors.As(err, &unsupportedType) {
+		return fmt.Errorf("%w: %w", ErrUnsupportedValue, err)
+	}
+
+	return err
+}
+
+func encodeProtobuf(w io.Writer, value any) error {
+	msg, ok := value.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%w: %T is not a proto.Message", ErrUnsupportedValue, value)
+	}
+
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func encodeMsgpack(w io.Writer, value any) error {
+	return msgpack.NewEncoder(w).Encode(value)
+}
+
+func encodeText(w io.Writer, value any) error {
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		if err != nil {
+			return err
+		}
+		text = string(b)
+	case fmt.Stringer:
+		text = v.String()
+	case error:
+		text = v.Error()
+	default:
+		return fmt.Errorf("%w: %T can not be encoded as text", ErrUnsupportedValue, value)
+	}
+
+	_, err := io.WriteString(w, text)
+	return err
+}
+
+// encodeCSV encodes [][]string values, CSVMarshaler values and slices of
+// structs, whose header is named after the "csv" tag of the fields.
+func encodeCSV(w io.Writer, value any) error {
+	var records [][]string
+	switch v := value.(type) {
+	case [][]string:
+		records = v
+	case CSVMarshaler:
+		var err error
+		if records, err = v.MarshalCSV(); err != nil {
+			return err
+		}
+	default:
+		var err error
+		if records, err = structRecords(value); err != nil {
+			return err
+		}
+	}
+
+	return csv.NewWriter(w).WriteAll(records)
+}
+
+func structRecords(value any) ([][]string, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("%w: %T can not be encoded as csv", ErrUnsupportedValue, value)
+	}
+
+	t := v.Type().Elem()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T can not be encoded as csv", ErrUnsupportedValue, value)
+	}
+
+	var header []string
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("csv")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		header = append(header, name)
+		fields = append(fields, i)
+	}
+
+	records := make([][]string, 0, v.Len()+1)
+	records = append(records, header)
+	for i := 0; i < v.Len(); i++ {
+		elem := reflect.Indirect(v.Index(i))
+		record := make([]string, len(fields))
+		if elem.IsValid() {
+			for j, field := range fields {
+				record[j] = fmt.Sprint(elem.Field(field).Interface())
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -11,6 +11,18 @@ type Error struct {
 	Message    string `json:"message"`
 	Code       string `json:"error"`
 	StatusCode int    `json:"status"`
+	// Details holds optional metadata about the error.
+	Details map[string]any `json:"details,omitempty"`
+	// Violations lists the fields that failed validation when binding a request.
+	Violations []Violation `json:"violations,omitempty"`
+	// Type is a URI reference that identifies the problem type, only rendered
+	// by routers responding Problem Details.
+	Type string `json:"-"`
+	// Extensions are additional members of the problem, only rendered by
+	// routers responding Problem Details.
+	Extensions map[string]any `json:"-"`
+
+	cause error
 }
 
 // Error returns a string message of the error. It is a concatenation of Code and Message fields.
@@ -31,17 +43,80 @@ func (e *Error) Error() string {
 	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, e.Message)
 }
 
+// Unwrap returns the error that caused e, if any.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithCause sets the error that caused e, which is returned by Unwrap.
+func (e *Error) WithCause(err error) *Error {
+	e.cause = err
+	return e
+}
+
+// WithCode sets the business error code of e, like "card_not_found",
+// replacing the one derived from the status code.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
+// WithDetail adds the metadata key to the details of e.
+func (e *Error) WithDetail(key string, value any) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// WithType sets the URI reference that identifies the problem type of the error.
+func (e *Error) WithType(uri string) *Error {
+	e.Type = uri
+	return e
+}
+
+// WithExtension adds the extension member key to the problem of the error.
+//
+//	return httprouter.NewError(http.StatusConflict, "card already exists").(*httprouter.Error).
+//		WithExtension("card_id", cardID)
+func (e *Error) WithExtension(key string, value any) *Error {
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]any)
+	}
+	e.Extensions[key] = value
+	return e
+}
+
 // NewError creates a new error with the given status code and message.
 func NewError(statusCode int, message string) error {
-	return NewErrorf(statusCode, message)
+	return newError(statusCode, message)
 }
 
 // NewErrorf creates a new error with the given status code and the message
 // formatted according to args and format.
 func NewErrorf(statusCode int, format string, args ...any) error {
+	return newError(statusCode, fmt.Sprintf(format, args...))
+}
+
+// WrapError creates a new error with the given status code that wraps err,
+// using its message.
+//
+//	return httprouter.WrapError(http.StatusConflict, err).WithCode("card_already_exists")
+func WrapError(statusCode int, err error) *Error {
+	return newError(statusCode, err.Error()).WithCause(err)
+}
+
+func newError(statusCode int, message string) *Error {
 	return &Error{
-		Code:       strings.ReplaceAll(strings.ToLower(http.StatusText(statusCode)), " ", "_"),
-		Message:    fmt.Sprintf(format, args...),
+		Code:       statusCodeText(statusCode),
+		Message:    message,
 		StatusCode: statusCode,
 	}
 }
+
+// statusCodeText returns the error code derived from the status code,
+// like "not_found" for http.StatusNotFound.
+func statusCodeText(statusCode int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(statusCode)), " ", "_")
+}
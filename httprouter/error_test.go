@@ -1,6 +1,9 @@
 package httprouter_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -20,3 +23,79 @@ func TestNewErrorf(t *testing.T) {
 	require.Error(t, err)
 	require.EqualValues(t, "400 bad_request: error occurred: detail", err.Error())
 }
+
+func TestNewError_MessageIsNotFormatted(t *testing.T) {
+	err := httprouter.NewError(http.StatusBadRequest, "discount can not exceed 100%")
+	require.EqualValues(t, "400 bad_request: discount can not exceed 100%", err.Error())
+}
+
+func TestWrapError(t *testing.T) {
+	errCardNotFound := errors.New("card not found")
+	cause := fmt.Errorf("getting card crd-1: %w", errCardNotFound)
+
+	err := httprouter.WrapError(http.StatusNotFound, cause).
+		WithCode("card_not_found").
+		WithDetail("card_id", "crd-1")
+
+	require.ErrorIs(t, err, errCardNotFound)
+	require.Equal(t, cause, errors.Unwrap(err))
+	require.EqualValues(t, "404 card_not_found: getting card crd-1: card not found", err.Error())
+
+	b, jsonErr := json.Marshal(err)
+	require.NoError(t, jsonErr)
+	require.JSONEq(t, `{"message":"getting card crd-1: card not found","error":"card_not_found","status":404,"details":{"card_id":"crd-1"}}`, string(b))
+}
+
+func TestDefaultHandlerError(t *testing.T) {
+	errCardNotFound := errors.New("card not found")
+	errCardBlocked := errors.New("card blocked")
+	httprouter.RegisterError(errCardNotFound, http.StatusNotFound, "card_not_found")
+	httprouter.RegisterError(errCardBlocked, http.StatusUnprocessableEntity, "")
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedNotify bool
+	}{
+		{
+			name:           "registered error",
+			err:            fmt.Errorf("getting card: %w", errCardNotFound),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "card_not_found",
+		},
+		{
+			name:           "registered error without code",
+			err:            errCardBlocked,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "unprocessable_entity",
+		},
+		{
+			name:           "web error takes precedence",
+			err:            httprouter.WrapError(http.StatusGone, errCardNotFound),
+			expectedStatus: http.StatusGone,
+			expectedCode:   "gone",
+		},
+		{
+			name:           "unknown error",
+			err:            errors.New("database is down"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedCode:   "internal_server_error",
+			expectedNotify: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handleErr := httprouter.DefaultHandlerError(tt.err)
+
+			webErr, ok := handleErr.Error.(*httprouter.Error)
+			require.True(t, ok)
+			require.Equal(t, tt.expectedStatus, handleErr.StatusCode)
+			require.Equal(t, tt.expectedCode, webErr.Code)
+			require.Equal(t, tt.expectedNotify, handleErr.Notify)
+			require.ErrorIs(t, webErr, tt.err)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package httprouter
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyRecord is the state of an idempotency key: the request that
+// first used it and, once completed, its response.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the method, path, query and body of the request.
+	Fingerprint string `json:"fingerprint"`
+	// Completed reports whether the response is stored, the request being
+	// in flight otherwise.
+	Completed bool `json:"completed"`
+	// StatusCode is the status code of the response.
+	StatusCode int `json:"status_code,omitempty"`
+	// Header holds the headers of the response.
+	Header http.Header `json:"header,omitempty"`
+	// Body is the body of the response.
+	Body []byte `json:"body,omitempty"`
+}
+
+// IdempotencyStore persists the records of the idempotency keys.
+//
+// Implementations backed by shared storage allow several instances of a
+// service to honor the same keys. Reserve must be atomic: only one of the
+// concurrent calls for the same key may reserve it.
+type IdempotencyStore interface {
+	// Reserve stores record under key for ttl when key is not stored, in
+	// which case it reports true. Otherwise, it returns the stored record.
+	Reserve(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (IdempotencyRecord, bool, error)
+	// Save replaces the record stored under key and persists it for ttl.
+	Save(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error
+	// Delete removes the record stored under key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryIdempotencyStore is an in-process IdempotencyStore. It is suitable
+// for tests and single instance services.
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryIdempotencyEntry
+	lastSweep time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	record    IdempotencyRecord
+	expiresAt time.Time
+}
+
+// NewMemoryIdempotencyStore instantiates an empty MemoryIdempotencyStore.
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries:   make(map[string]memoryIdempotencyEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+//revive:disable:unused-parameter
+
+// Reserve stores record under key for ttl when key is not stored.
+func (s *MemoryIdempotencyStore) Reserve(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if entry, ok := s.entries[key]; ok && !now.After(entry.expiresAt) {
+		return entry.record, false, nil
+	}
+
+	s.entries[key] = memoryIdempotencyEntry{record: record, expiresAt: now.Add(ttl)}
+
+	return record, true, nil
+}
+
+// Save replaces the record stored under key and persists it for ttl.
+func (s *MemoryIdempotencyStore) Save(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryIdempotencyEntry{record: record, expiresAt: time.Now().Add(ttl)}
+
+	return nil
+}
+
+// Delete removes the record stored under key, if any.
+func (s *MemoryIdempotencyStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+
+	return nil
+}
+
+//revive:enable:unused-parameter
+
+// sweep evicts the expired keys at most once every _sweepInterval.
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < _sweepInterval {
+		return
+	}
+
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
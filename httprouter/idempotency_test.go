@@ -0,0 +1,52 @@
+package httprouter_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func TestMemoryIdempotencyStore(t *testing.T) {
+	store := httprouter.NewMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	record, reserved, err := store.Reserve(ctx, "a", httprouter.IdempotencyRecord{Fingerprint: "first"}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, "first", record.Fingerprint)
+
+	record, reserved, err = store.Reserve(ctx, "a", httprouter.IdempotencyRecord{Fingerprint: "second"}, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, httprouter.IdempotencyRecord{Fingerprint: "first"}, record)
+
+	completed := httprouter.IdempotencyRecord{
+		Fingerprint: "first",
+		Completed:   true,
+		StatusCode:  http.StatusCreated,
+		Body:        []byte(`{}`),
+	}
+	require.NoError(t, store.Save(ctx, "a", completed, time.Minute))
+
+	record, reserved, err = store.Reserve(ctx, "a", httprouter.IdempotencyRecord{Fingerprint: "first"}, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, completed, record)
+
+	require.NoError(t, store.Delete(ctx, "a"))
+	_, reserved, err = store.Reserve(ctx, "a", httprouter.IdempotencyRecord{Fingerprint: "second"}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+
+	_, _, err = store.Reserve(ctx, "expired", httprouter.IdempotencyRecord{Fingerprint: "first"}, -time.Second)
+	require.NoError(t, err)
+	_, reserved, err = store.Reserve(ctx, "expired", httprouter.IdempotencyRecord{Fingerprint: "second"}, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+}
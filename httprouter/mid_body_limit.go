@@ -0,0 +1,69 @@
+package httprouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// MaxBodySize produces a middleware that limits the body of the requests to
+// the given number of bytes. Requests whose Content-Length is over the limit
+// are answered with HTTP 413, while reading past the limit fails with an
+// *http.MaxBytesError, which Bind and DefaultHandlerError convert into an
+// Error with http.StatusRequestEntityTooLarge.
+//
+// Use WithMaxBodySize to limit the body of a single route.
+func MaxBodySize(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return Handler(func(w http.ResponseWriter, r *http.Request) error {
+			if err := limitBody(w, r, limit); err != nil {
+				return err
+			}
+
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
+
+// limitBodyHandler limits the body of the requests handled by handler to
+// limit bytes.
+func limitBodyHandler(handler Handler, limit int64) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if err := limitBody(w, r, limit); err != nil {
+			return err
+		}
+
+		return handler(w, r)
+	}
+}
+
+// limitBody rejects the requests whose Content-Length is over limit and caps
+// the body of the rest.
+func limitBody(w http.ResponseWriter, r *http.Request, limit int64) error {
+	if r.ContentLength > limit {
+		return errBodyTooLarge(limit)
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+
+	return nil
+}
+
+// bodyTooLarge converts err into an Error with
+// http.StatusRequestEntityTooLarge when it is caused by reading a body past
+// its limit.
+func bodyTooLarge(err error) (*Error, bool) {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return nil, false
+	}
+
+	return errBodyTooLarge(maxBytesErr.Limit), true
+}
+
+func errBodyTooLarge(limit int64) *Error {
+	return newError(http.StatusRequestEntityTooLarge, fmt.Sprintf("request body too large, the limit is %d bytes", limit))
+}
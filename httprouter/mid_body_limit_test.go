@@ -0,0 +1,126 @@
+package httprouter_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func TestMidMaxBodySize(t *testing.T) {
+	type createCard struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		chunked     bool
+		wantStatus  int
+	}{
+		{
+			name:       "body under the limit",
+			body:       `{"name":"a"}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "content length over the limit",
+			body:       `{"name":"` + strings.Repeat("a", 32) + `"}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:       "body over the limit",
+			body:       `{"name":"` + strings.Repeat("a", 32) + `"}`,
+			chunked:    true,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "form over the limit",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "name=" + strings.Repeat("a", 32),
+			chunked:     true,
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := httprouter.New(httprouter.WithProblemDetails(true))
+			app.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+				var req createCard
+				if err := httprouter.Bind(r, &req); err != nil {
+					return err
+				}
+				return httprouter.RespondJSON(w, http.StatusCreated, req)
+			}, httprouter.WithMaxBodySize(16))
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/cards", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				request.Header.Set("Content-Type", tc.contentType)
+			}
+			if tc.chunked {
+				request.ContentLength = -1
+			}
+
+			app.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.wantStatus, recorder.Code)
+			if tc.wantStatus == http.StatusRequestEntityTooLarge {
+				var problem httprouter.Problem
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&problem))
+				assert.Equal(t, "request body too large, the limit is 16 bytes", problem.Detail)
+			}
+		})
+	}
+}
+
+func TestMidMaxBodySize_Middleware(t *testing.T) {
+	app := httprouter.New()
+	app.With(httprouter.MaxBodySize(4)).Post("/upload", func(w http.ResponseWriter, r *http.Request) error {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			return err
+		}
+		return httprouter.RespondJSON(w, http.StatusNoContent, nil)
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		chunked    bool
+		wantStatus int
+	}{
+		{name: "body under the limit", body: "abcd", wantStatus: http.StatusNoContent},
+		{name: "content length over the limit", body: "abcde", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "body over the limit", body: "abcde", chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(tc.body))
+			if tc.chunked {
+				request.ContentLength = -1
+			}
+
+			app.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.wantStatus, recorder.Code)
+			if tc.wantStatus == http.StatusRequestEntityTooLarge {
+				var body httprouter.Error
+				require.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+				assert.Equal(t, httprouter.Error{
+					Message:    "request body too large, the limit is 4 bytes",
+					Code:       "request_entity_too_large",
+					StatusCode: http.StatusRequestEntityTooLarge,
+				}, body)
+			}
+		})
+	}
+}
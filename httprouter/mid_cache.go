@@ -0,0 +1,276 @@
+package httprouter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CacheOptions represents the options for configuring the Cache middleware.
+type CacheOptions struct {
+	CacheControl *CacheControl
+	WeakETag     bool
+	Vary         []string
+	Store        *ResponseCache
+	TTL          time.Duration
+}
+
+// WithCacheControl allows you to configure the caching policy sent in the
+// "Cache-Control" header of the responses that do not set one.
+//
+// Default behavior is to send no "Cache-Control" header.
+func WithCacheControl(policy CacheControl) func(opts *CacheOptions) {
+	return func(opts *CacheOptions) {
+		opts.CacheControl = &policy
+	}
+}
+
+// WithWeakETag allows you to configure the middleware to compute weak ETags,
+// like `W/"..."`, which should be preferred when the responses are
+// compressed, since their bytes then differ from the hashed ones.
+//
+// Default behavior is to compute strong ETags.
+func WithWeakETag() func(opts *CacheOptions) {
+	return func(opts *CacheOptions) {
+		opts.WeakETag = true
+	}
+}
+
+// WithCacheVary allows you to configure the request headers the responses
+// vary by, which are announced in the "Vary" header and key the
+// ResponseCache, e.g. "Accept" for routes that use Respond.
+func WithCacheVary(headers ...string) func(opts *CacheOptions) {
+	return func(opts *CacheOptions) {
+		opts.Vary = append(opts.Vary, headers...)
+	}
+}
+
+// WithResponseCache allows you to configure the middleware to keep the
+// responses in the given ResponseCache for ttl and serve the later requests
+// from it, without calling the handler. Responses with a "Set-Cookie" header,
+// or whose "Cache-Control" header has the private or no-store directives,
+// are not kept. Neither are the responses to requests with an
+// "Authorization" header, unless their policy has the public, s-maxage or
+// must-revalidate directives, as defined by RFC 9111.
+//
+// The cache is shared by every client, so the responses that depend on the
+// identity of the client must vary by the header that carries it, like
+// WithCacheVary("Authorization"), or must not be kept at all.
+//
+// Default behavior is to call the handler on every request.
+func WithResponseCache(cache *ResponseCache, ttl time.Duration) func(opts *CacheOptions) {
+	return func(opts *CacheOptions) {
+		opts.Store = cache
+		opts.TTL = ttl
+	}
+}
+
+// Cache produces a middleware that makes the responses of GET and HEAD
+// requests cacheable by the clients.
+//
+// The http.StatusOK responses carry the caching policy and an ETag computed
+// from their body, unless the handler sets one, and are answered with
+// http.StatusNotModified when the ETag matches the "If-None-Match" header,
+// or when the "Last-Modified" header set by the handler is not after the
+// "If-Modified-Since" header of the request.
+//
+// The responses are buffered in order to hash them, so the middleware must
+// not be used with streamed responses, like the ones of NewSSE.
+func Cache(optFns ...func(opts *CacheOptions)) func(http.Handler) http.Handler {
+	var opts CacheOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := cacheKey(r, opts.Vary)
+			if opts.Store != nil {
+				if res, ok := opts.Store.get(key); ok {
+					header := w.Header()
+					for name, values := range res.header {
+						header[name] = values
+					}
+					respondConditional(w, r, res.statusCode, res.body)
+					return
+				}
+			}
+
+			cw := &cacheWriter{header: w.Header()}
+			next.ServeHTTP(cw, r)
+
+			status := cw.statusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				_, _ = w.Write(cw.body.Bytes())
+				return
+			}
+
+			header := w.Header()
+			if opts.CacheControl != nil && header.Get("Cache-Control") == "" {
+				header.Set("Cache-Control", opts.CacheControl.String())
+			}
+			for _, name := range opts.Vary {
+				addVary(header, name)
+			}
+			if header.Get("ETag") == "" && r.Method == http.MethodGet {
+				header.Set("ETag", computeETag(cw.body.Bytes(), opts.WeakETag))
+			}
+
+			if opts.Store != nil && opts.TTL > 0 && r.Method == http.MethodGet && storable(r, header) {
+				stored := header.Clone()
+				for _, name := range _replayIgnoredHeaders {
+					stored.Del(name)
+				}
+
+				opts.Store.set(&cachedResponse{
+					key:        key,
+					statusCode: status,
+					header:     stored,
+					body:       cw.body.Bytes(),
+					expiresAt:  time.Now().Add(opts.TTL),
+				})
+			}
+
+			respondConditional(w, r, status, cw.body.Bytes())
+		})
+	}
+}
+
+// cacheWriter buffers the response of a handler, sharing the headers of the
+// response writer it replaces.
+type cacheWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *cacheWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *cacheWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *cacheWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	return w.body.Write(b)
+}
+
+// respondConditional writes the response, or http.StatusNotModified when the
+// client already holds it.
+func respondConditional(w http.ResponseWriter, r *http.Request, status int, body []byte) {
+	if status == http.StatusOK && notModified(r, w.Header()) {
+		w.Header().Del("Content-Type")
+		w.Header().Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+// notModified evaluates the "If-None-Match" header of the request or, in its
+// absence, the "If-Modified-Since" header, as defined by RFC 9110.
+func notModified(r *http.Request, header http.Header) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		etag := header.Get("ETag")
+		return etag != "" && etagMatch(ifNoneMatch, etag)
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.After(since)
+}
+
+// computeETag hashes body into a strong or weak ETag.
+func computeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// cacheKey identifies the response to a request by the pattern of its route,
+// its path and query and the values of the headers it varies by.
+func cacheKey(r *http.Request, vary []string) string {
+	var key strings.Builder
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		key.WriteString(rctx.RoutePattern())
+	}
+	key.WriteString(" " + r.URL.RequestURI())
+	for _, name := range vary {
+		key.WriteString("\n" + name + ": " + strings.Join(r.Header.Values(name), ","))
+	}
+
+	return key.String()
+}
+
+// addVary adds name to the "Vary" header, unless it is already present.
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), name) {
+				return
+			}
+		}
+	}
+
+	header.Add("Vary", name)
+}
+
+// storable reports whether a shared cache may store the response to r, as
+// defined by RFC 9111. Responses to requests with an "Authorization" header
+// are only stored when their policy explicitly allows it.
+func storable(r *http.Request, header http.Header) bool {
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+
+	authorized := r.Header.Get("Authorization") != ""
+	explicit := false
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(directive)), "=")
+		switch name {
+		case "private", "no-store":
+			return false
+		case "public", "s-maxage", "must-revalidate":
+			explicit = true
+		}
+	}
+
+	return !authorized || explicit
+}
@@ -0,0 +1,283 @@
+package httprouter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+var _lastModified = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func TestMidCache(t *testing.T) {
+	tests := []struct {
+		name       string
+		optFns     []func(opts *httprouter.CacheOptions)
+		method     string
+		path       string
+		header     http.Header
+		wantStatus int
+		wantBody   string
+		wantHeader http.Header
+	}{
+		{
+			name:       "sets a strong etag",
+			method:     http.MethodGet,
+			path:       "/cards",
+			wantStatus: http.StatusOK,
+			wantBody:   `["crd-1"]`,
+			wantHeader: http.Header{"Etag": {`"176baf3bbbe07755d30aa0418d29b7f1"`}},
+		},
+		{
+			name:       "sets a weak etag",
+			optFns:     []func(opts *httprouter.CacheOptions){httprouter.WithWeakETag()},
+			method:     http.MethodGet,
+			path:       "/cards",
+			wantStatus: http.StatusOK,
+			wantBody:   `["crd-1"]`,
+			wantHeader: http.Header{"Etag": {`W/"176baf3bbbe07755d30aa0418d29b7f1"`}},
+		},
+		{
+			name:       "matches if-none-match",
+			method:     http.MethodGet,
+			path:       "/cards",
+			header:     http.Header{"If-None-Match": {`"v0", W/"176baf3bbbe07755d30aa0418d29b7f1"`}},
+			wantStatus: http.StatusNotModified,
+			wantHeader: http.Header{"Etag": {`"176baf3bbbe07755d30aa0418d29b7f1"`}},
+		},
+		{
+			name:       "does not match if-none-match",
+			method:     http.MethodGet,
+			path:       "/cards",
+			header:     http.Header{"If-None-Match": {`"v0"`}},
+			wantStatus: http.StatusOK,
+			wantBody:   `["crd-1"]`,
+		},
+		{
+			name:       "keeps the etag of the handler",
+			method:     http.MethodGet,
+			path:       "/cards/crd-1",
+			header:     http.Header{"If-None-Match": {`"crd-1"`}},
+			wantStatus: http.StatusNotModified,
+			wantHeader: http.Header{"Etag": {`"crd-1"`}},
+		},
+		{
+			name:       "not modified since",
+			method:     http.MethodGet,
+			path:       "/cards/crd-1",
+			header:     http.Header{"If-Modified-Since": {_lastModified.Format(http.TimeFormat)}},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "modified since",
+			method:     http.MethodGet,
+			path:       "/cards/crd-1",
+			header:     http.Header{"If-Modified-Since": {_lastModified.Add(-time.Hour).Format(http.TimeFormat)}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"crd-1"`,
+		},
+		{
+			name:       "if-none-match takes precedence",
+			method:     http.MethodGet,
+			path:       "/cards/crd-1",
+			header:     http.Header{"If-None-Match": {`"v0"`}, "If-Modified-Since": {_lastModified.Format(http.TimeFormat)}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"crd-1"`,
+		},
+		{
+			name: "sets the cache policy",
+			optFns: []func(opts *httprouter.CacheOptions){
+				httprouter.WithCacheControl(httprouter.CacheControl{Public: true, MaxAge: time.Minute}),
+				httprouter.WithCacheVary("Accept", "Accept-Language"),
+			},
+			method:     http.MethodGet,
+			path:       "/cards",
+			wantStatus: http.StatusOK,
+			wantBody:   `["crd-1"]`,
+			wantHeader: http.Header{"Cache-Control": {"public, max-age=60"}, "Vary": {"Accept", "Accept-Language"}},
+		},
+		{
+			name:       "ignores errors",
+			optFns:     []func(opts *httprouter.CacheOptions){httprouter.WithCacheControl(httprouter.CacheControl{MaxAge: time.Minute})},
+			method:     http.MethodGet,
+			path:       "/cards/crd-2",
+			wantStatus: http.StatusNotFound,
+			wantHeader: http.Header{"Cache-Control": nil, "Etag": nil},
+		},
+		{
+			name:       "ignores unsafe methods",
+			method:     http.MethodPost,
+			path:       "/cards",
+			wantStatus: http.StatusCreated,
+			wantHeader: http.Header{"Etag": nil},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := httprouter.New()
+			app.Use(httprouter.Cache(tc.optFns...))
+			app.Get("/cards", func(w http.ResponseWriter, r *http.Request) error {
+				return httprouter.RespondJSON(w, http.StatusOK, []string{"crd-1"})
+			})
+			app.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+				return httprouter.RespondJSON(w, http.StatusCreated, "crd-2")
+			})
+			app.Get("/cards/{id}", func(w http.ResponseWriter, r *http.Request) error {
+				if httprouter.URLParam(r, "id") != "crd-1" {
+					return httprouter.NewError(http.StatusNotFound, "card not found")
+				}
+				w.Header().Set("ETag", `"crd-1"`)
+				w.Header().Set("Last-Modified", _lastModified.Format(http.TimeFormat))
+				return httprouter.RespondJSON(w, http.StatusOK, "crd-1")
+			})
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(tc.method, tc.path, nil)
+			for name, values := range tc.header {
+				request.Header[name] = values
+			}
+
+			app.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.wantStatus, recorder.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, recorder.Body.String())
+			}
+			if tc.wantStatus == http.StatusNotModified {
+				assert.Empty(t, recorder.Body.String())
+				assert.Empty(t, recorder.Header().Get("Content-Type"))
+			}
+			for name, values := range tc.wantHeader {
+				assert.Equal(t, values, recorder.Header().Values(name), name)
+			}
+		})
+	}
+}
+
+func TestMidCache_ResponseCache(t *testing.T) {
+	var calls atomic.Int32
+	cache := httprouter.NewResponseCache(2)
+
+	getCard := func(w http.ResponseWriter, r *http.Request) error {
+		calls.Add(1)
+		if httprouter.URLParam(r, "id") == "private" {
+			w.Header().Set("Cache-Control", "private")
+		}
+		return httprouter.RespondJSON(w, http.StatusOK, httprouter.URLParam(r, "id")+" "+r.Header.Get("Accept-Language"))
+	}
+
+	app := httprouter.New()
+	cached := app.With(httprouter.Cache(
+		httprouter.WithResponseCache(cache, time.Minute),
+		httprouter.WithCacheVary("Accept-Language"),
+	))
+	cached.Get("/cards/{id}", getCard)
+	cached.Head("/cards/{id}", getCard)
+
+	send := func(method, path, language string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(method, path, nil)
+		request.Header.Set("Accept-Language", language)
+		app.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := send(http.MethodGet, "/cards/crd-1", "es")
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, int32(1), calls.Load())
+
+	hit := send(http.MethodGet, "/cards/crd-1", "es")
+	require.Equal(t, http.StatusOK, hit.Code)
+	assert.Equal(t, `"crd-1 es"`, hit.Body.String())
+	assert.Equal(t, first.Header().Get("ETag"), hit.Header().Get("ETag"))
+	assert.Equal(t, "application/json", hit.Header().Get("Content-Type"))
+	assert.Equal(t, int32(1), calls.Load())
+
+	head := send(http.MethodHead, "/cards/crd-1", "es")
+	require.Equal(t, http.StatusOK, head.Code)
+	assert.Empty(t, head.Body.String())
+	assert.Equal(t, first.Header().Get("ETag"), head.Header().Get("ETag"))
+	assert.Equal(t, int32(1), calls.Load())
+
+	varied := send(http.MethodGet, "/cards/crd-1", "en")
+	assert.Equal(t, `"crd-1 en"`, varied.Body.String())
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, 2, cache.Len())
+
+	send(http.MethodGet, "/cards/private", "es")
+	send(http.MethodGet, "/cards/private", "es")
+	assert.Equal(t, int32(4), calls.Load())
+	assert.Equal(t, 2, cache.Len())
+
+	send(http.MethodGet, "/cards/crd-2", "es")
+	assert.Equal(t, 2, cache.Len())
+	send(http.MethodGet, "/cards/crd-1", "es")
+	assert.Equal(t, int32(6), calls.Load(), "least recently used response is evicted")
+
+	cache.Purge()
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestMidCache_ResponseCacheAuthorization(t *testing.T) {
+	tests := []struct {
+		name      string
+		optFns    []func(opts *httprouter.CacheOptions)
+		wantCalls int32
+	}{
+		{
+			name:      "does not store authorized responses",
+			wantCalls: 2,
+		},
+		{
+			name:      "stores explicitly public responses",
+			optFns:    []func(opts *httprouter.CacheOptions){httprouter.WithCacheControl(httprouter.CacheControl{Public: true, MaxAge: time.Minute})},
+			wantCalls: 1,
+		},
+		{
+			name:      "stores responses with a shared max age",
+			optFns:    []func(opts *httprouter.CacheOptions){httprouter.WithCacheControl(httprouter.CacheControl{SharedMaxAge: time.Minute})},
+			wantCalls: 1,
+		},
+		{
+			name:      "does not store private responses",
+			optFns:    []func(opts *httprouter.CacheOptions){httprouter.WithCacheControl(httprouter.CacheControl{Private: true, MaxAge: time.Minute})},
+			wantCalls: 2,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			cache := httprouter.NewResponseCache(10)
+
+			app := httprouter.New()
+			app.With(httprouter.Cache(append(tc.optFns, httprouter.WithResponseCache(cache, time.Minute))...)).
+				Get("/me", func(w http.ResponseWriter, r *http.Request) error {
+					calls.Add(1)
+					return httprouter.RespondJSON(w, http.StatusOK, r.Header.Get("Authorization"))
+				})
+
+			first := httptest.NewRecorder()
+			request := httptest.NewRequest(http.MethodGet, "/me", nil)
+			request.Header.Set("Authorization", "Bearer alice")
+			app.ServeHTTP(first, request)
+			require.Equal(t, http.StatusOK, first.Code)
+
+			second := httptest.NewRecorder()
+			request = httptest.NewRequest(http.MethodGet, "/me", nil)
+			request.Header.Set("Authorization", "Bearer bob")
+			app.ServeHTTP(second, request)
+			require.Equal(t, http.StatusOK, second.Code)
+
+			assert.Equal(t, tc.wantCalls, calls.Load())
+			if tc.wantCalls == 2 {
+				assert.Equal(t, `"Bearer bob"`, second.Body.String())
+			}
+		})
+	}
+}
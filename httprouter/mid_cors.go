@@ -0,0 +1,323 @@
+package httprouter
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	_headerOrigin                        = "Origin"
+	_headerAccessControlRequestMethod    = "Access-Control-Request-Method"
+	_headerAccessControlRequestHeaders   = "Access-Control-Request-Headers"
+	_headerAccessControlAllowOrigin      = "Access-Control-Allow-Origin"
+	_headerAccessControlAllowMethods     = "Access-Control-Allow-Methods"
+	_headerAccessControlAllowHeaders     = "Access-Control-Allow-Headers"
+	_headerAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	_headerAccessControlExposeHeaders    = "Access-Control-Expose-Headers"
+	_headerAccessControlMaxAge           = "Access-Control-Max-Age"
+)
+
+// CORSOptions represents the options for configuring the CORS middleware.
+type CORSOptions struct {
+	Origins          []string
+	OriginPatterns   []*regexp.Regexp
+	Methods          []string
+	Headers          []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// WithCORSOrigins allows you to configure the origins allowed to access the
+// routes, either exact, like "https://app.pomelo.la", with a wildcard
+// subdomain, like "https://*.pomelo.la", or "*" to allow any origin.
+//
+// Default behavior is to allow any origin.
+func WithCORSOrigins(origins ...string) func(opts *CORSOptions) {
+	return func(opts *CORSOptions) {
+		opts.Origins = append(opts.Origins, origins...)
+	}
+}
+
+// WithCORSOriginPatterns allows you to configure the regular expressions
+// matching the origins allowed to access the routes, along with the ones
+// configured with WithCORSOrigins.
+func WithCORSOriginPatterns(patterns ...*regexp.Regexp) func(opts *CORSOptions) {
+	return func(opts *CORSOptions) {
+		opts.OriginPatterns = append(opts.OriginPatterns, patterns...)
+	}
+}
+
+// WithCORSMethods allows you to configure the methods allowed in cross-origin
+// requests.
+//
+// Default behavior is to allow GET, HEAD, POST, PUT, PATCH and DELETE.
+func WithCORSMethods(methods ...string) func(opts *CORSOptions) {
+	return func(opts *CORSOptions) {
+		opts.Methods = methods
+	}
+}
+
+// WithCORSHeaders allows you to configure the request headers allowed in
+// cross-origin requests, or "*" to allow any header.
+//
+// Default behavior is to allow the Accept, Authorization and Content-Type headers.
+func WithCORSHeaders(headers ...string) func(opts *CORSOptions) {
+	return func(opts *CORSOptions) {
+		opts.Headers = headers
+	}
+}
+
+// WithCORSExposedHeaders allows you to configure the response headers that
+// the browser exposes to the client, besides the CORS-safelisted ones.
+func WithCORSExposedHeaders(headers ...string) func(opts *CORSOptions) {
+	return func(opts *CORSOptions) {
+		opts.ExposedHeaders = append(opts.ExposedHeaders, headers...)
+	}
+}
+
+// WithCORSCredentials allows you to configure the routes to accept
+// cross-origin requests with credentials, like cookies. The allowed origins
+// must be configured with WithCORSOrigins or WithCORSOriginPatterns, and
+// can not include "*", otherwise CORS and Router.WithCORS panic.
+//
+// Default behavior is to not accept credentials.
+func WithCORSCredentials() func(opts *CORSOptions) {
+	return func(opts *CORSOptions) {
+		opts.AllowCredentials = true
+	}
+}
+
+// WithCORSMaxAge allows you to configure for how long the browser may cache
+// the result of a preflight request.
+//
+// Default behavior is to let the browser decide.
+func WithCORSMaxAge(maxAge time.Duration) func(opts *CORSOptions) {
+	return func(opts *CORSOptions) {
+		opts.MaxAge = maxAge
+	}
+}
+
+// CORS produces a middleware that allows browsers to access the routes from
+// other origins, as defined by the Fetch standard, answering the preflight
+// requests with HTTP 204.
+//
+// Preflight requests are OPTIONS requests, so the middleware only sees them
+// when it is used on the router created with New, or when the route has an
+// OPTIONS handler. Use Router.WithCORS to enable CORS on a group of routes.
+func CORS(optFns ...func(opts *CORSOptions)) func(http.Handler) http.Handler {
+	return newCORSPolicy(optFns).handler
+}
+
+// WithCORS returns a Router whose routes are enabled for CORS with the given
+// options, like With(CORS(optFns...)) does, that also registers an OPTIONS
+// handler for each of them that has none, so their preflight requests are
+// answered. Other OPTIONS requests are answered with HTTP 405, and an OPTIONS
+// route registered on the router takes precedence over that handler.
+//
+//	api := r.WithCORS(httprouter.WithCORSOrigins("https://*.pomelo.la"))
+//	api.Get("/cards/{id}", getCard)
+//	api.Post("/cards", createCard)
+func (r *Router) WithCORS(optFns ...func(opts *CORSOptions)) *Router {
+	subRouter := r.With(newCORSPolicy(optFns).handler)
+	subRouter.cors = true
+
+	return subRouter
+}
+
+// corsPolicy is the compiled configuration of a CORS middleware.
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]struct{}
+	wildcards        [][2]string
+	patterns         []*regexp.Regexp
+	methods          []string
+	anyHeader        bool
+	headers          map[string]struct{}
+	allowedHeaders   string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+func newCORSPolicy(optFns []func(opts *CORSOptions)) *corsPolicy {
+	opts := CORSOptions{
+		Methods: []string{
+			http.MethodGet, http.MethodHead, http.MethodPost,
+			http.MethodPut, http.MethodPatch, http.MethodDelete,
+		},
+		Headers: []string{"Accept", "Authorization", "Content-Type"},
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	p := &corsPolicy{
+		anyOrigin:        len(opts.Origins) == 0 && len(opts.OriginPatterns) == 0,
+		origins:          make(map[string]struct{}),
+		patterns:         opts.OriginPatterns,
+		methods:          opts.Methods,
+		headers:          make(map[string]struct{}),
+		exposedHeaders:   strings.Join(opts.ExposedHeaders, ", "),
+		allowCredentials: opts.AllowCredentials,
+	}
+
+	for _, origin := range opts.Origins {
+		origin = strings.ToLower(origin)
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			p.wildcards = append(p.wildcards, [2]string{prefix, suffix})
+		default:
+			p.origins[origin] = struct{}{}
+		}
+	}
+
+	if p.allowCredentials && p.anyOrigin {
+		panic("httprouter: attempting to allow CORS credentials from any origin, configure the allowed origins")
+	}
+
+	canonical := make([]string, 0, len(opts.Headers))
+	for _, header := range opts.Headers {
+		if header == "*" {
+			p.anyHeader = true
+			continue
+		}
+		header = http.CanonicalHeaderKey(header)
+		p.headers[header] = struct{}{}
+		canonical = append(canonical, header)
+	}
+	p.allowedHeaders = strings.Join(canonical, ", ")
+
+	if opts.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(seconds(opts.MaxAge))
+	}
+
+	return p
+}
+
+func (p *corsPolicy) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		origin := r.Header.Get(_headerOrigin)
+
+		if r.Method == http.MethodOptions && r.Header.Get(_headerAccessControlRequestMethod) != "" {
+			addVary(header, _headerOrigin)
+			addVary(header, _headerAccessControlRequestMethod)
+			addVary(header, _headerAccessControlRequestHeaders)
+
+			if p.allowOrigin(origin) &&
+				p.allowMethod(r.Header.Get(_headerAccessControlRequestMethod)) &&
+				p.allowHeaders(r.Header.Get(_headerAccessControlRequestHeaders)) {
+				p.setOrigin(header, origin)
+				header.Set(_headerAccessControlAllowMethods, strings.Join(p.methods, ", "))
+				if requested := r.Header.Get(_headerAccessControlRequestHeaders); p.anyHeader && requested != "" {
+					header.Set(_headerAccessControlAllowHeaders, requested)
+				} else if p.allowedHeaders != "" {
+					header.Set(_headerAccessControlAllowHeaders, p.allowedHeaders)
+				}
+				if p.maxAge != "" {
+					header.Set(_headerAccessControlMaxAge, p.maxAge)
+				}
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		addVary(header, _headerOrigin)
+		if p.allowOrigin(origin) {
+			p.setOrigin(header, origin)
+			if p.exposedHeaders != "" {
+				header.Set(_headerAccessControlExposeHeaders, p.exposedHeaders)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setOrigin allows origin to read the response, along with its credentials
+// if configured.
+func (p *corsPolicy) setOrigin(header http.Header, origin string) {
+	if p.anyOrigin {
+		header.Set(_headerAccessControlAllowOrigin, "*")
+	} else {
+		header.Set(_headerAccessControlAllowOrigin, origin)
+	}
+
+	if p.allowCredentials {
+		header.Set(_headerAccessControlAllowCredentials, "true")
+	}
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.anyOrigin {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	if _, ok := p.origins[lower]; ok {
+		return true
+	}
+	for _, w := range p.wildcards {
+		if len(lower) > len(w[0])+len(w[1]) && strings.HasPrefix(lower, w[0]) && strings.HasSuffix(lower, w[1]) {
+			return true
+		}
+	}
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *corsPolicy) allowMethod(method string) bool {
+	for _, m := range p.methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *corsPolicy) allowHeaders(requested string) bool {
+	if p.anyHeader {
+		return true
+	}
+
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if _, ok := p.headers[http.CanonicalHeaderKey(header)]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// corsOptionsHandler is the OPTIONS handler registered for the routes of the
+// routers created with WithCORS. Their preflight requests are answered by
+// the CORS middleware before reaching it, so it only handles the OPTIONS
+// requests that are not preflight requests, which the route does not allow.
+//
+//revive:disable:unused-parameter
+func corsOptionsHandler(w http.ResponseWriter, r *http.Request) error {
+	return NewError(http.StatusMethodNotAllowed, "method not allowed")
+}
+
+//revive:enable:unused-parameter
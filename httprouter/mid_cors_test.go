@@ -0,0 +1,296 @@
+package httprouter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func TestMidCORS(t *testing.T) {
+	tests := []struct {
+		name       string
+		optFns     []func(opts *httprouter.CORSOptions)
+		method     string
+		header     http.Header
+		wantStatus int
+		wantHeader http.Header
+	}{
+		{
+			name:       "any origin",
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://app.pomelo.la"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin": {"*"},
+				"Vary":                        {"Origin"},
+			},
+		},
+		{
+			name:       "same origin request",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Access-Control-Allow-Origin": nil},
+		},
+		{
+			name:       "exact origin",
+			optFns:     []func(opts *httprouter.CORSOptions){httprouter.WithCORSOrigins("https://app.pomelo.la")},
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://App.Pomelo.la"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Access-Control-Allow-Origin": {"https://App.Pomelo.la"}},
+		},
+		{
+			name:       "wildcard subdomain",
+			optFns:     []func(opts *httprouter.CORSOptions){httprouter.WithCORSOrigins("https://*.pomelo.la")},
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://dashboard.pomelo.la"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Access-Control-Allow-Origin": {"https://dashboard.pomelo.la"}},
+		},
+		{
+			name:       "wildcard requires a subdomain",
+			optFns:     []func(opts *httprouter.CORSOptions){httprouter.WithCORSOrigins("https://*.pomelo.la")},
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://pomelo.la"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Access-Control-Allow-Origin": nil},
+		},
+		{
+			name: "origin pattern",
+			optFns: []func(opts *httprouter.CORSOptions){
+				httprouter.WithCORSOriginPatterns(regexp.MustCompile(`^http://localhost:\d+$`)),
+			},
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"http://localhost:3000"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Access-Control-Allow-Origin": {"http://localhost:3000"}},
+		},
+		{
+			name:       "disallowed origin",
+			optFns:     []func(opts *httprouter.CORSOptions){httprouter.WithCORSOrigins("https://app.pomelo.la")},
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://evil.com"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{"Access-Control-Allow-Origin": nil},
+		},
+		{
+			name: "credentials and exposed headers",
+			optFns: []func(opts *httprouter.CORSOptions){
+				httprouter.WithCORSOrigins("https://app.pomelo.la"),
+				httprouter.WithCORSCredentials(),
+				httprouter.WithCORSExposedHeaders("Location", "X-Request-Id"),
+			},
+			method:     http.MethodGet,
+			header:     http.Header{"Origin": {"https://app.pomelo.la"}},
+			wantStatus: http.StatusOK,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin":      {"https://app.pomelo.la"},
+				"Access-Control-Allow-Credentials": {"true"},
+				"Access-Control-Expose-Headers":    {"Location, X-Request-Id"},
+			},
+		},
+		{
+			name: "preflight",
+			optFns: []func(opts *httprouter.CORSOptions){
+				httprouter.WithCORSOrigins("https://app.pomelo.la"),
+				httprouter.WithCORSMethods(http.MethodGet, http.MethodPost),
+				httprouter.WithCORSHeaders("content-type", "Idempotency-Key"),
+				httprouter.WithCORSMaxAge(10 * time.Minute),
+			},
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.pomelo.la"},
+				"Access-Control-Request-Method":  {http.MethodPost},
+				"Access-Control-Request-Headers": {"content-type,idempotency-key"},
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin":  {"https://app.pomelo.la"},
+				"Access-Control-Allow-Methods": {"GET, POST"},
+				"Access-Control-Allow-Headers": {"Content-Type, Idempotency-Key"},
+				"Access-Control-Max-Age":       {"600"},
+				"Vary":                         {"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+			},
+		},
+		{
+			name:   "preflight with any header",
+			optFns: []func(opts *httprouter.CORSOptions){httprouter.WithCORSHeaders("*")},
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.pomelo.la"},
+				"Access-Control-Request-Method":  {http.MethodPost},
+				"Access-Control-Request-Headers": {"x-custom"},
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeader: http.Header{"Access-Control-Allow-Headers": {"x-custom"}},
+		},
+		{
+			name:   "preflight with disallowed method",
+			optFns: []func(opts *httprouter.CORSOptions){httprouter.WithCORSMethods(http.MethodGet)},
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                        {"https://app.pomelo.la"},
+				"Access-Control-Request-Method": {http.MethodDelete},
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeader: http.Header{"Access-Control-Allow-Origin": nil, "Access-Control-Allow-Methods": nil},
+		},
+		{
+			name:   "preflight with disallowed header",
+			method: http.MethodOptions,
+			header: http.Header{
+				"Origin":                         {"https://app.pomelo.la"},
+				"Access-Control-Request-Method":  {http.MethodPost},
+				"Access-Control-Request-Headers": {"X-Custom"},
+			},
+			wantStatus: http.StatusNoContent,
+			wantHeader: http.Header{"Access-Control-Allow-Origin": nil},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := httprouter.New()
+			api := app.WithCORS(tc.optFns...)
+			api.Get("/cards", func(w http.ResponseWriter, r *http.Request) error {
+				return httprouter.RespondJSON(w, http.StatusOK, nil)
+			})
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(tc.method, "/cards", nil)
+			for name, values := range tc.header {
+				request.Header[name] = values
+			}
+
+			app.ServeHTTP(recorder, request)
+
+			assert.Equal(t, tc.wantStatus, recorder.Code)
+			for name, values := range tc.wantHeader {
+				assert.Equal(t, values, recorder.Header().Values(name), name)
+			}
+		})
+	}
+}
+
+func TestMidCORS_CredentialsFromAnyOrigin(t *testing.T) {
+	tests := []struct {
+		name   string
+		optFns []func(opts *httprouter.CORSOptions)
+	}{
+		{
+			name:   "no origins",
+			optFns: []func(opts *httprouter.CORSOptions){httprouter.WithCORSCredentials()},
+		},
+		{
+			name: "wildcard origin",
+			optFns: []func(opts *httprouter.CORSOptions){
+				httprouter.WithCORSOrigins("https://app.pomelo.la", "*"),
+				httprouter.WithCORSCredentials(),
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Panics(t, func() { httprouter.CORS(tc.optFns...) })
+			assert.Panics(t, func() { httprouter.New().WithCORS(tc.optFns...) })
+		})
+	}
+
+	assert.NotPanics(t, func() {
+		httprouter.CORS(
+			httprouter.WithCORSOriginPatterns(regexp.MustCompile(`^https://[a-z]+\.pomelo\.la$`)),
+			httprouter.WithCORSCredentials(),
+		)
+	})
+}
+
+func TestMidCORS_Group(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusOK, nil)
+	}
+
+	app := httprouter.New()
+	app.Get("/internal", h)
+	app.WithCORS(httprouter.WithCORSOrigins("https://app.pomelo.la")).Group(func(r httprouter.Router) {
+		r.Get("/cards/{id}", h)
+		r.Route("/users", func(r httprouter.Router) {
+			r.Post("/", h)
+		})
+	})
+
+	preflight := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodOptions, path, nil)
+		request.Header.Set("Origin", "https://app.pomelo.la")
+		request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		app.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := preflight("/cards/crd-1")
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://app.pomelo.la", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	recorder = preflight("/users")
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://app.pomelo.la", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	recorder = preflight("/internal")
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	routes, err := app.Routes()
+	require.NoError(t, err)
+
+	var methods []string
+	for _, route := range routes {
+		if route.Route == "/cards/{id}" {
+			methods = append(methods, route.Method)
+		}
+	}
+	assert.ElementsMatch(t, []string{http.MethodGet, http.MethodOptions}, methods)
+}
+
+func TestMidCORS_OptionsRoute(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusOK, nil)
+	}
+
+	app := httprouter.New()
+	api := app.WithCORS(httprouter.WithCORSOrigins("https://app.pomelo.la"))
+	api.Options("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Allow", "GET, OPTIONS")
+		return httprouter.RespondJSON(w, http.StatusOK, "cards")
+	})
+	api.Get("/cards", h)
+	api.Get("/users", h)
+
+	send := func(path string, header http.Header) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodOptions, path, nil)
+		request.Header = header
+		app.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	recorder := send("/cards", http.Header{})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "GET, OPTIONS", recorder.Header().Get("Allow"))
+	assert.Equal(t, `"cards"`, recorder.Body.String())
+
+	recorder = send("/cards", http.Header{
+		"Origin":                        {"https://app.pomelo.la"},
+		"Access-Control-Request-Method": {http.MethodGet},
+	})
+	require.Equal(t, http.StatusNoContent, recorder.Code)
+	assert.Equal(t, "https://app.pomelo.la", recorder.Header().Get("Access-Control-Allow-Origin"))
+
+	recorder = send("/users", http.Header{})
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
@@ -0,0 +1,263 @@
+package httprouter
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const (
+	// HeaderIdempotencyKey is the request header holding the idempotency key.
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed is the response header set on the responses
+	// replayed from the IdempotencyStore.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	_defaultIdempotencyTTL         = 24 * time.Hour
+	_defaultIdempotencyLockTimeout = time.Minute
+)
+
+// _replayIgnoredHeaders are the response headers that describe the
+// encoding of a single response, which are not replayed.
+var _replayIgnoredHeaders = []string{
+	"Content-Encoding",
+	"Content-Length",
+	"Date",
+	"Transfer-Encoding",
+}
+
+// IdempotencyOptions represents the options for configuring the Idempotency middleware.
+type IdempotencyOptions struct {
+	Store       IdempotencyStore
+	TTL         time.Duration
+	LockTimeout time.Duration
+	ScopeFunc   func(r *http.Request) string
+	Prefix      string
+	Required    bool
+}
+
+// WithIdempotencyStore allows you to configure the store in which the
+// responses are kept, e.g. a shared backend so that every instance of the
+// service honors the same keys.
+//
+// Default behavior is to use a new MemoryIdempotencyStore.
+func WithIdempotencyStore(store IdempotencyStore) func(opts *IdempotencyOptions) {
+	return func(opts *IdempotencyOptions) {
+		opts.Store = store
+	}
+}
+
+// WithIdempotencyTTL allows you to configure for how long the response of a
+// key is replayed.
+//
+// Default behavior is to replay responses for 24 hours.
+func WithIdempotencyTTL(ttl time.Duration) func(opts *IdempotencyOptions) {
+	return func(opts *IdempotencyOptions) {
+		opts.TTL = ttl
+	}
+}
+
+// WithIdempotencyLockTimeout allows you to configure for how long a key is
+// held by a request in flight, after which it is released in case the
+// instance serving the request went away.
+//
+// Default behavior is to hold keys for 1 minute.
+func WithIdempotencyLockTimeout(timeout time.Duration) func(opts *IdempotencyOptions) {
+	return func(opts *IdempotencyOptions) {
+		opts.LockTimeout = timeout
+	}
+}
+
+// WithIdempotencyScope allows you to configure the identity the keys are
+// scoped to, so that different clients can use the same key, e.g.
+// auth.IdentityKey or KeyByHeader.
+//
+// Default behavior is to share the keys among every client.
+func WithIdempotencyScope(scopeFunc func(r *http.Request) string) func(opts *IdempotencyOptions) {
+	return func(opts *IdempotencyOptions) {
+		opts.ScopeFunc = scopeFunc
+	}
+}
+
+// WithIdempotencyPrefix allows you to configure a prefix for the keys, in
+// order to share a store across several middlewares.
+func WithIdempotencyPrefix(prefix string) func(opts *IdempotencyOptions) {
+	return func(opts *IdempotencyOptions) {
+		opts.Prefix = prefix
+	}
+}
+
+// WithIdempotencyRequired allows you to configure the middleware to answer
+// HTTP 400 to the requests without an Idempotency-Key header.
+//
+// Default behavior is to serve the requests without a key as they are.
+func WithIdempotencyRequired() func(opts *IdempotencyOptions) {
+	return func(opts *IdempotencyOptions) {
+		opts.Required = true
+	}
+}
+
+// Idempotency produces a middleware that honors the Idempotency-Key header of
+// the requests with unsafe methods, like POST and PATCH.
+//
+// The response to the first request with a key (status code, headers and
+// body) is stored, and replayed with the Idempotent-Replayed header to the
+// later requests with the same key. Requests reusing a key while the first
+// one is in flight, or with a different method, path, query or body, are
+// answered with HTTP 409. Responses with a 5xx status code are not stored,
+// so the request can be retried. If the store fails the request is answered
+// with HTTP 503. Errors are responded in the format of the router.
+//
+// The body of the requests is read in full to fingerprint them, use
+// MaxBodySize in front of the middleware to bound it. Bodies over the limit
+// are answered with HTTP 413.
+func Idempotency(optFns ...func(opts *IdempotencyOptions)) func(http.Handler) http.Handler {
+	opts := IdempotencyOptions{
+		TTL:         _defaultIdempotencyTTL,
+		LockTimeout: _defaultIdempotencyLockTimeout,
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewMemoryIdempotencyStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return Handler(func(w http.ResponseWriter, r *http.Request) error {
+			if isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return nil
+			}
+
+			key := r.Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				if opts.Required {
+					return NewErrorf(http.StatusBadRequest, "missing %s header", HeaderIdempotencyKey)
+				}
+
+				next.ServeHTTP(w, r)
+				return nil
+			}
+
+			fingerprint, err := fingerprintRequest(r)
+			if tooLarge, ok := bodyTooLarge(err); ok {
+				return tooLarge
+			}
+			if err != nil {
+				return newError(http.StatusBadRequest, "failed to read request body").WithCause(err)
+			}
+
+			storeKey := opts.Prefix + key
+			if opts.ScopeFunc != nil {
+				storeKey = opts.Prefix + opts.ScopeFunc(r) + ":" + key
+			}
+
+			ctx := r.Context()
+			record, reserved, err := opts.Store.Reserve(ctx, storeKey, IdempotencyRecord{Fingerprint: fingerprint}, opts.LockTimeout)
+			if err != nil {
+				return newError(http.StatusServiceUnavailable, "idempotency store unavailable").WithCause(err)
+			}
+
+			if !reserved {
+				switch {
+				case record.Fingerprint != fingerprint:
+					return NewErrorf(http.StatusConflict, "%s was used with a different request", HeaderIdempotencyKey)
+				case !record.Completed:
+					return NewErrorf(http.StatusConflict, "a request with the same %s is in progress", HeaderIdempotencyKey)
+				default:
+					replay(w, record)
+					return nil
+				}
+			}
+
+			var body bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&body)
+
+			// The response must be stored, or the key released, even when the
+			// client goes away while the handler runs.
+			storeCtx := context.WithoutCancel(ctx)
+			completed := false
+			defer func() {
+				if !completed {
+					_ = opts.Store.Delete(storeCtx, storeKey)
+				}
+			}()
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return nil
+			}
+
+			header := w.Header().Clone()
+			for _, name := range _replayIgnoredHeaders {
+				header.Del(name)
+			}
+
+			record = IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Completed:   true,
+				StatusCode:  status,
+				Header:      header,
+				Body:        body.Bytes(),
+			}
+			completed = opts.Store.Save(storeCtx, storeKey, record, opts.TTL) == nil
+			return nil
+		})
+	}
+}
+
+// isSafeMethod reports whether method is safe as defined by RFC 9110.
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// fingerprintRequest hashes the method, path, query and body of the request,
+// restoring its body to be read by the next handler.
+func fingerprintRequest(r *http.Request) (string, error) {
+	h := sha256.New()
+	_, _ = io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return "", err
+		}
+		_ = r.Body.Close()
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// replay writes the stored response of record.
+func replay(w http.ResponseWriter, record IdempotencyRecord) {
+	header := w.Header()
+	for name, values := range record.Header {
+		header[name] = values
+	}
+	header.Set(HeaderIdempotentReplayed, "true")
+
+	w.WriteHeader(record.StatusCode)
+	_, _ = w.Write(record.Body)
+}
@@ -0,0 +1,337 @@
+package httprouter_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+type failingIdempotencyStore struct{}
+
+//revive:disable:unused-parameter
+func (failingIdempotencyStore) Reserve(ctx context.Context, key string, record httprouter.IdempotencyRecord, ttl time.Duration) (httprouter.IdempotencyRecord, bool, error) {
+	return httprouter.IdempotencyRecord{}, false, errors.New("store unavailable")
+}
+
+func (failingIdempotencyStore) Save(ctx context.Context, key string, record httprouter.IdempotencyRecord, ttl time.Duration) error {
+	return errors.New("store unavailable")
+}
+
+func (failingIdempotencyStore) Delete(ctx context.Context, key string) error {
+	return errors.New("store unavailable")
+}
+
+//revive:enable:unused-parameter
+
+// canceledIdempotencyStore fails to save with a canceled context, like a store
+// backed by a remote service.
+type canceledIdempotencyStore struct {
+	*httprouter.MemoryIdempotencyStore
+}
+
+func (s canceledIdempotencyStore) Save(ctx context.Context, key string, record httprouter.IdempotencyRecord, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.MemoryIdempotencyStore.Save(ctx, key, record, ttl)
+}
+
+type idempotentRequest struct {
+	method string
+	path   string
+	key    string
+	body   string
+	header http.Header
+}
+
+func TestMidIdempotency(t *testing.T) {
+	tests := []struct {
+		name       string
+		optFns     []func(opts *httprouter.IdempotencyOptions)
+		status     int
+		requests   []idempotentRequest
+		wantStatus []int
+		wantCalls  int32
+	}{
+		{
+			name:   "replays the response of a key",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{method: http.MethodPost, path: "/cards", key: "k1", body: `{"name":"a"}`},
+				{method: http.MethodPost, path: "/cards", key: "k1", body: `{"name":"a"}`},
+				{method: http.MethodPost, path: "/cards", key: "k2", body: `{"name":"a"}`},
+			},
+			wantStatus: []int{http.StatusCreated, http.StatusCreated, http.StatusCreated},
+			wantCalls:  2,
+		},
+		{
+			name:   "rejects a key reused with a different body",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{method: http.MethodPost, path: "/cards", key: "k1", body: `{"name":"a"}`},
+				{method: http.MethodPost, path: "/cards", key: "k1", body: `{"name":"b"}`},
+			},
+			wantStatus: []int{http.StatusCreated, http.StatusConflict},
+			wantCalls:  1,
+		},
+		{
+			name:   "rejects a key reused with a different path",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{method: http.MethodPost, path: "/cards", key: "k1"},
+				{method: http.MethodPost, path: "/cards?type=virtual", key: "k1"},
+			},
+			wantStatus: []int{http.StatusCreated, http.StatusConflict},
+			wantCalls:  1,
+		},
+		{
+			name:   "ignores safe methods",
+			status: http.StatusOK,
+			requests: []idempotentRequest{
+				{method: http.MethodGet, path: "/cards", key: "k1"},
+				{method: http.MethodGet, path: "/cards", key: "k1"},
+			},
+			wantStatus: []int{http.StatusOK, http.StatusOK},
+			wantCalls:  2,
+		},
+		{
+			name:   "serves requests without a key",
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{method: http.MethodPost, path: "/cards"},
+				{method: http.MethodPost, path: "/cards"},
+			},
+			wantStatus: []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:  2,
+		},
+		{
+			name:   "requires a key",
+			optFns: []func(opts *httprouter.IdempotencyOptions){httprouter.WithIdempotencyRequired()},
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{method: http.MethodPost, path: "/cards"},
+				{method: http.MethodPost, path: "/cards", key: "k1"},
+			},
+			wantStatus: []int{http.StatusBadRequest, http.StatusCreated},
+			wantCalls:  1,
+		},
+		{
+			name:   "does not store server errors",
+			status: http.StatusBadGateway,
+			requests: []idempotentRequest{
+				{method: http.MethodPost, path: "/cards", key: "k1"},
+				{method: http.MethodPost, path: "/cards", key: "k1"},
+			},
+			wantStatus: []int{http.StatusBadGateway, http.StatusBadGateway},
+			wantCalls:  2,
+		},
+		{
+			name:   "scopes keys",
+			optFns: []func(opts *httprouter.IdempotencyOptions){httprouter.WithIdempotencyScope(httprouter.KeyByHeader("X-Client-Id"))},
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{method: http.MethodPost, path: "/cards", key: "k1", header: http.Header{"X-Client-Id": {"a"}}},
+				{method: http.MethodPost, path: "/cards", key: "k1", header: http.Header{"X-Client-Id": {"b"}}, body: `{}`},
+				{method: http.MethodPost, path: "/cards", key: "k1", header: http.Header{"X-Client-Id": {"a"}}},
+			},
+			wantStatus: []int{http.StatusCreated, http.StatusCreated, http.StatusCreated},
+			wantCalls:  2,
+		},
+		{
+			name:   "rejects requests when the store fails",
+			optFns: []func(opts *httprouter.IdempotencyOptions){httprouter.WithIdempotencyStore(failingIdempotencyStore{})},
+			status: http.StatusCreated,
+			requests: []idempotentRequest{
+				{method: http.MethodPost, path: "/cards", key: "k1"},
+			},
+			wantStatus: []int{http.StatusServiceUnavailable},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			h := func(w http.ResponseWriter, r *http.Request) error {
+				calls.Add(1)
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					return err
+				}
+				return httprouter.RespondJSON(w, tc.status, string(body))
+			}
+
+			app := httprouter.New()
+			app.Use(httprouter.Idempotency(tc.optFns...))
+			app.Get("/cards", h)
+			app.Post("/cards", h)
+
+			for i, req := range tc.requests {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body))
+				for name, values := range req.header {
+					request.Header[name] = values
+				}
+				if req.key != "" {
+					request.Header.Set(httprouter.HeaderIdempotencyKey, req.key)
+				}
+
+				app.ServeHTTP(recorder, request)
+
+				assert.Equal(t, tc.wantStatus[i], recorder.Code, "request %d", i)
+			}
+			assert.Equal(t, tc.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestMidIdempotency_Replay(t *testing.T) {
+	var calls atomic.Int32
+	app := httprouter.New()
+	app.Use(httprouter.Idempotency())
+	app.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("Location", "/cards/crd-1")
+		return httprouter.RespondJSON(w, http.StatusCreated, map[string]int32{"call": calls.Add(1)})
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/cards", strings.NewReader(`{"name":"a"}`))
+		request.Header.Set(httprouter.HeaderIdempotencyKey, "k1")
+		app.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	first := send()
+	require.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(httprouter.HeaderIdempotentReplayed))
+
+	second := send()
+	require.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get(httprouter.HeaderIdempotentReplayed))
+	assert.Equal(t, "/cards/crd-1", second.Header().Get("Location"))
+	assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"call":1}`, second.Body.String())
+}
+
+func TestMidIdempotency_InFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	app := httprouter.New()
+	app.Use(httprouter.Idempotency())
+	app.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		close(started)
+		<-release
+		return httprouter.RespondJSON(w, http.StatusCreated, nil)
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/cards", nil)
+		request.Header.Set(httprouter.HeaderIdempotencyKey, "k1")
+		app.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- send() }()
+	<-started
+
+	recorder := send()
+	require.Equal(t, http.StatusConflict, recorder.Code)
+
+	var body httprouter.Error
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	assert.Equal(t, httprouter.Error{
+		Message:    "a request with the same Idempotency-Key is in progress",
+		Code:       "conflict",
+		StatusCode: http.StatusConflict,
+	}, body)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.Equal(t, http.StatusCreated, send().Code)
+}
+
+func TestMidIdempotency_BodyTooLarge(t *testing.T) {
+	var calls atomic.Int32
+	app := httprouter.New()
+	app.Use(httprouter.MaxBodySize(8), httprouter.Idempotency())
+	app.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		calls.Add(1)
+		return httprouter.RespondJSON(w, http.StatusCreated, nil)
+	})
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/cards", strings.NewReader(`{"name":"a"}`))
+	request.Header.Set(httprouter.HeaderIdempotencyKey, "k1")
+	request.ContentLength = -1
+	app.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+	var body httprouter.Error
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	assert.Equal(t, "request body too large, the limit is 8 bytes", body.Message)
+	assert.Zero(t, calls.Load())
+}
+
+func TestMidIdempotency_ClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var calls atomic.Int32
+	app := httprouter.New()
+	app.Use(httprouter.Idempotency(httprouter.WithIdempotencyStore(canceledIdempotencyStore{httprouter.NewMemoryIdempotencyStore()})))
+	app.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		calls.Add(1)
+		// The client goes away while the request is handled.
+		cancel()
+		return httprouter.RespondJSON(w, http.StatusCreated, nil)
+	})
+
+	send := func(ctx context.Context) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodPost, "/cards", nil).WithContext(ctx)
+		request.Header.Set(httprouter.HeaderIdempotencyKey, "k1")
+		app.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	require.Equal(t, http.StatusCreated, send(ctx).Code)
+
+	recorder := send(context.Background())
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get(httprouter.HeaderIdempotentReplayed))
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMidIdempotency_RouterFormat(t *testing.T) {
+	app := httprouter.New(httprouter.WithProblemDetails(true))
+	app.Use(httprouter.Idempotency(httprouter.WithIdempotencyRequired()))
+	app.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusCreated, nil)
+	})
+
+	recorder := httptest.NewRecorder()
+	app.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/cards", nil))
+
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+
+	var problem map[string]any
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&problem))
+	assert.Equal(t, "missing Idempotency-Key header", problem["detail"])
+	assert.Equal(t, "/cards", problem["instance"])
+}
@@ -0,0 +1,150 @@
+package httprouter
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// _ownerHeader is the header in which the auth package sets the owner of the
+// token in its legacy headers mode.
+const _ownerHeader = "owner"
+
+// RateLimitKeyFunc extracts from a request the identity its quota is tracked by.
+type RateLimitKeyFunc func(r *http.Request) string
+
+// KeyByRemoteIP tracks the quota by the IP address of the client. Use chi's
+// middleware.RealIP in front of the rate limiter when running behind a
+// trusted proxy.
+func KeyByRemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// KeyByHeader tracks the quota by the value of the given header.
+func KeyByHeader(header string) RateLimitKeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(header)
+	}
+}
+
+// KeyByOwner tracks the quota by the owner of the token, as set in the owner
+// header by the legacy headers mode of the auth package. Prefer
+// auth.IdentityKey, which reads the identity from the request context.
+//
+// The owner header is sent by the client, so it is only trusted when the
+// rate limiter runs after auth.SetLegacyHeaders, or a Decoder created with
+// auth.WithLegacyHeaders, replaced it with the owner of a verified token.
+// Otherwise any client can pick the quota it consumes.
+func KeyByOwner(r *http.Request) string {
+	return r.Header.Get(_ownerHeader)
+}
+
+// RateLimitOptions represents the options for configuring the RateLimit middleware.
+type RateLimitOptions struct {
+	KeyFunc RateLimitKeyFunc
+	Store   RateLimitStore
+	Prefix  string
+}
+
+// WithRateLimitKey allows you to configure how the identity of a request is
+// obtained. Requests for which the KeyFunc returns an empty string are
+// tracked by the IP address of the client.
+//
+// Default behavior is to use KeyByRemoteIP.
+func WithRateLimitKey(keyFunc RateLimitKeyFunc) func(opts *RateLimitOptions) {
+	return func(opts *RateLimitOptions) {
+		opts.KeyFunc = keyFunc
+	}
+}
+
+// WithRateLimitStore allows you to configure the store in which the quotas
+// are kept, e.g. a shared backend so that every instance of the service
+// enforces the same quota.
+//
+// Default behavior is to use a new MemoryRateLimitStore.
+func WithRateLimitStore(store RateLimitStore) func(opts *RateLimitOptions) {
+	return func(opts *RateLimitOptions) {
+		opts.Store = store
+	}
+}
+
+// WithRateLimitPrefix allows you to configure a prefix for the keys, in order
+// to share a store across several rate limiters.
+func WithRateLimitPrefix(prefix string) func(opts *RateLimitOptions) {
+	return func(opts *RateLimitOptions) {
+		opts.Prefix = prefix
+	}
+}
+
+// RateLimit produces a middleware that limits the requests of every client
+// according to the given algorithm, see TokenBucket and SlidingWindow.
+//
+// Every response carries the RateLimit-Policy, RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers. Requests over the quota
+// are answered with HTTP 429 and a Retry-After header, in the format of the
+// router. If the store fails the request is let through.
+func RateLimit(algorithm RateLimitAlgorithm, optFns ...func(opts *RateLimitOptions)) func(http.Handler) http.Handler {
+	opts := RateLimitOptions{
+		KeyFunc: KeyByRemoteIP,
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewMemoryRateLimitStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return Handler(func(w http.ResponseWriter, r *http.Request) error {
+			key := opts.KeyFunc(r)
+			if key == "" {
+				key = KeyByRemoteIP(r)
+			}
+
+			now := time.Now()
+			var result RateLimitResult
+			err := opts.Store.Update(r.Context(), opts.Prefix+key, algorithm.TTL(), func(state *RateLimitState) {
+				result = algorithm.Take(state, now)
+			})
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return nil
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Policy", algorithm.Policy())
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+
+			if !result.Allowed {
+				retryAfter := seconds(result.RetryAfter)
+				header.Set("Retry-After", strconv.Itoa(retryAfter))
+
+				return NewErrorf(http.StatusTooManyRequests, "rate limit exceeded, retry in %d seconds", retryAfter)
+			}
+
+			next.ServeHTTP(w, r)
+			return nil
+		})
+	}
+}
+
+// seconds rounds up the given duration to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// policy formats a quota as in the RateLimit-Policy header.
+func policy(limit int, window time.Duration) string {
+	return fmt.Sprintf("%d;w=%d", limit, seconds(window))
+}
@@ -0,0 +1,150 @@
+package httprouter_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+type failingStore struct{}
+
+//revive:disable:unused-parameter
+func (failingStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state *httprouter.RateLimitState)) error {
+	return errors.New("store unavailable")
+}
+
+//revive:enable:unused-parameter
+
+func TestMidRateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		optFns     []func(opts *httprouter.RateLimitOptions)
+		requests   []func(r *http.Request)
+		wantStatus []int
+	}{
+		{
+			name: "limits by remote ip",
+			requests: []func(r *http.Request){
+				func(r *http.Request) { r.RemoteAddr = "10.0.0.1:1234" },
+				func(r *http.Request) { r.RemoteAddr = "10.0.0.1:4321" },
+				func(r *http.Request) { r.RemoteAddr = "10.0.0.2:1234" },
+			},
+			wantStatus: []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:   "limits by owner",
+			optFns: []func(opts *httprouter.RateLimitOptions){httprouter.WithRateLimitKey(httprouter.KeyByOwner)},
+			requests: []func(r *http.Request){
+				func(r *http.Request) { r.Header.Set("owner", "a@pomelo.la") },
+				func(r *http.Request) { r.Header.Set("owner", "b@pomelo.la") },
+				func(r *http.Request) { r.Header.Set("owner", "a@pomelo.la") },
+			},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:   "falls back to remote ip on missing header",
+			optFns: []func(opts *httprouter.RateLimitOptions){httprouter.WithRateLimitKey(httprouter.KeyByHeader("X-Client-Id"))},
+			requests: []func(r *http.Request){
+				func(r *http.Request) {},
+				func(r *http.Request) { r.Header.Set("X-Client-Id", "client") },
+				func(r *http.Request) {},
+			},
+			wantStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:   "lets requests through when the store fails",
+			optFns: []func(opts *httprouter.RateLimitOptions){httprouter.WithRateLimitStore(failingStore{})},
+			requests: []func(r *http.Request){
+				func(r *http.Request) {},
+				func(r *http.Request) {},
+			},
+			wantStatus: []int{http.StatusOK, http.StatusOK},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			app := httprouter.New()
+			app.Use(httprouter.RateLimit(httprouter.SlidingWindow(1, time.Minute), tc.optFns...))
+			app.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+				return httprouter.RespondJSON(w, http.StatusOK, nil)
+			})
+
+			for i, setup := range tc.requests {
+				recorder := httptest.NewRecorder()
+				request := httptest.NewRequest(http.MethodGet, "/", nil)
+				setup(request)
+
+				app.ServeHTTP(recorder, request)
+
+				assert.Equal(t, tc.wantStatus[i], recorder.Code, "request %d", i)
+			}
+		})
+	}
+}
+
+func TestMidRateLimit_Headers(t *testing.T) {
+	app := httprouter.New()
+	app.Use(httprouter.RateLimit(httprouter.TokenBucket(1, time.Minute, 1)))
+	app.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusOK, nil)
+	})
+
+	recorder := httptest.NewRecorder()
+	app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "1;w=60", recorder.Header().Get("RateLimit-Policy"))
+	assert.Equal(t, "1", recorder.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", recorder.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", recorder.Header().Get("RateLimit-Reset"))
+	assert.Empty(t, recorder.Header().Get("Retry-After"))
+
+	recorder = httptest.NewRecorder()
+	app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "60", recorder.Header().Get("Retry-After"))
+
+	var body httprouter.Error
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	assert.Equal(t, httprouter.Error{
+		Message:    "rate limit exceeded, retry in 60 seconds",
+		Code:       "too_many_requests",
+		StatusCode: http.StatusTooManyRequests,
+	}, body)
+}
+
+func TestMidRateLimit_RouterFormat(t *testing.T) {
+	app := httprouter.New(
+		httprouter.WithProblemDetails(true),
+		httprouter.WithErrorHandlerFunc(func(err error, defaultHandlerError func(error) httprouter.HandlerError) httprouter.HandlerError {
+			handleErr := defaultHandlerError(err)
+			handleErr.Error.(*httprouter.Error).Code = "slow_down"
+			return handleErr
+		}),
+	)
+	app.With(httprouter.RateLimit(httprouter.TokenBucket(1, time.Minute, 1))).Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.RespondJSON(w, http.StatusOK, nil)
+	})
+
+	app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	recorder := httptest.NewRecorder()
+	app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "60", recorder.Header().Get("Retry-After"))
+
+	var problem map[string]any
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&problem))
+	assert.EqualValues(t, http.StatusTooManyRequests, problem["status"])
+	assert.Equal(t, "rate limit exceeded, retry in 60 seconds", problem["detail"])
+	assert.Equal(t, "slow_down", problem["code"])
+}
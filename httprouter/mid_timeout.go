@@ -0,0 +1,136 @@
+package httprouter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout produces a middleware that cancels the context of the requests
+// once the given timeout elapses.
+//
+// Requests whose handler is still running by then are answered with
+// HTTP 503, while the handler's writes are discarded, and the errors caused
+// by a deadline, like the ones of the clients called with the context of the
+// request, are answered with HTTP 504. The responses are buffered until the
+// handler returns, so the middleware must not be used with streamed
+// responses, like the ones of NewSSE.
+//
+// Use WithTimeout to limit a single route, responding the errors in the
+// format of its router.
+func Timeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return timeoutHandler(func(w http.ResponseWriter, r *http.Request) error {
+			next.ServeHTTP(w, r)
+			return nil
+		}, timeout)
+	}
+}
+
+// timeoutHandler cancels the context of the requests handled by handler
+// after timeout, answering them if handler is still running.
+func timeoutHandler(handler Handler, timeout time.Duration) Handler {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{header: w.Header().Clone()}
+		done := make(chan error, 1)
+		panicked := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			done <- handler(tw, r.WithContext(ctx))
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case err := <-done:
+			tw.flush(w)
+
+			var webErr *Error
+			if err != nil && !errors.As(err, &webErr) && errors.Is(err, context.DeadlineExceeded) {
+				return newError(http.StatusGatewayTimeout, "request timed out").WithCause(err)
+			}
+			return err
+		case <-ctx.Done():
+			tw.timeout()
+
+			// The client is gone, there is no one to answer to.
+			if r.Context().Err() != nil {
+				return r.Context().Err()
+			}
+			return newError(http.StatusServiceUnavailable, "request timed out").WithCause(ctx.Err())
+		}
+	}
+}
+
+// timeoutWriter buffers the response of a handler until it returns, in
+// order to discard it if it times out.
+type timeoutWriter struct {
+	mu       sync.Mutex
+	header   http.Header
+	body     bytes.Buffer
+	code     int
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+
+	return tw.body.Write(b)
+}
+
+// timeout discards the writes of the handler from now on.
+func (tw *timeoutWriter) timeout() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+}
+
+// flush sends the buffered response to w.
+func (tw *timeoutWriter) flush(w http.ResponseWriter) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	header := w.Header()
+	for name, values := range tw.header {
+		header[name] = values
+	}
+
+	if tw.code == 0 {
+		return
+	}
+	w.WriteHeader(tw.code)
+	_, _ = w.Write(tw.body.Bytes())
+}
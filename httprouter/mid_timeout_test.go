@@ -0,0 +1,131 @@
+package httprouter_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func TestMidTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    func(release <-chan struct{}) httprouter.Handler
+		wantStatus int
+		wantBody   string
+		wantHeader string
+	}{
+		{
+			name: "handler in time",
+			handler: func(release <-chan struct{}) httprouter.Handler {
+				return func(w http.ResponseWriter, r *http.Request) error {
+					w.Header().Set("X-Card-Id", "crd-1")
+					return httprouter.RespondJSON(w, http.StatusOK, "crd-1")
+				}
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   `"crd-1"`,
+			wantHeader: "crd-1",
+		},
+		{
+			name: "handler times out",
+			handler: func(release <-chan struct{}) httprouter.Handler {
+				return func(w http.ResponseWriter, r *http.Request) error {
+					<-release
+					w.Header().Set("X-Card-Id", "crd-1")
+					return httprouter.RespondJSON(w, http.StatusOK, "crd-1")
+				}
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   `{"message":"request timed out","error":"service_unavailable","status":503}`,
+		},
+		{
+			name: "dependency times out",
+			handler: func(release <-chan struct{}) httprouter.Handler {
+				return func(w http.ResponseWriter, r *http.Request) error {
+					return fmt.Errorf("calling cards service: %w", context.DeadlineExceeded)
+				}
+			},
+			wantStatus: http.StatusGatewayTimeout,
+			wantBody:   `{"message":"request timed out","error":"gateway_timeout","status":504}`,
+		},
+		{
+			name: "handler error",
+			handler: func(release <-chan struct{}) httprouter.Handler {
+				return func(w http.ResponseWriter, r *http.Request) error {
+					return httprouter.NewError(http.StatusNotFound, "card not found")
+				}
+			},
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"message":"card not found","error":"not_found","status":404}`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			release := make(chan struct{})
+			defer close(release)
+
+			app := httprouter.New()
+			app.Get("/cards", tc.handler(release), httprouter.WithTimeout(20*time.Millisecond))
+
+			recorder := httptest.NewRecorder()
+			app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/cards", nil))
+
+			assert.Equal(t, tc.wantStatus, recorder.Code)
+			assert.JSONEq(t, tc.wantBody, recorder.Body.String())
+			assert.Equal(t, tc.wantHeader, recorder.Header().Get("X-Card-Id"))
+		})
+	}
+}
+
+func TestMidTimeout_Middleware(t *testing.T) {
+	canceled := make(chan error, 1)
+
+	app := httprouter.New()
+	app.With(httprouter.Timeout(20*time.Millisecond)).Get("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		<-r.Context().Done()
+		canceled <- r.Context().Err()
+		return nil
+	})
+
+	recorder := httptest.NewRecorder()
+	app.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/cards", nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	var body httprouter.Error
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	assert.Equal(t, "request timed out", body.Message)
+	assert.ErrorIs(t, <-canceled, context.DeadlineExceeded)
+}
+
+func TestMidTimeout_Panic(t *testing.T) {
+	app := httprouter.New()
+	app.Get("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	}, httprouter.WithTimeout(time.Second))
+
+	assert.PanicsWithValue(t, "boom", func() {
+		app.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cards", nil))
+	})
+}
+
+func TestRouterRoutes_Limits(t *testing.T) {
+	app := httprouter.New()
+	app.Post("/cards", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}, httprouter.WithMaxBodySize(1<<20), httprouter.WithTimeout(5*time.Second))
+
+	routes, err := app.Routes()
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, int64(1<<20), routes[0].Options.MaxBodySize)
+	assert.Equal(t, 5*time.Second, routes[0].Options.Timeout)
+}
@@ -0,0 +1,357 @@
+package httprouter
+
+import (
+	"html/template"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	_openAPIVersion         = "3.1.0"
+	_defaultOpenAPIPath     = "/openapi.json"
+	_defaultOpenAPITitle    = "API"
+	_defaultOpenAPIVersion  = "1.0.0"
+	_openAPIComponentPrefix = "#/components/schemas/"
+	// _defaultDocsAssetsURL pins the release of Swagger UI, so that a new one
+	// is never loaded without being reviewed.
+	_defaultDocsAssetsURL = "https://unpkg.com/swagger-ui-dist@5.17.14"
+)
+
+// OpenAPIConfig represents the configuration of the OpenAPI document of a Router.
+type OpenAPIConfig struct {
+	// Path is the path the document is served at, "/openapi.json" by default.
+	Path string
+	// DocsPath is the path the documentation UI is served at. The UI is not
+	// served when empty.
+	DocsPath string
+	// DocsAssetsURL is the base URL the swagger-ui.css and swagger-ui-bundle.js
+	// files of the documentation UI are loaded from by the browser, a pinned
+	// release of swagger-ui-dist on unpkg.com by default. Set it to a copy
+	// hosted by the service, or by a trusted CDN, to not depend on unpkg.com.
+	DocsAssetsURL   string
+	Info            OpenAPIInfo
+	Servers         []OpenAPIServer
+	SecuritySchemes map[string]OpenAPISecurityScheme
+}
+
+// OpenAPI is an OpenAPI 3.1 document.
+type OpenAPI struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Servers    []OpenAPIServer                         `json:"servers,omitempty"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+// OpenAPIInfo is the metadata of the API.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIServer is a server that serves the API.
+type OpenAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPISecurityScheme is a security scheme that can be used by the routes,
+// like {Type: "http", Scheme: "bearer", BearerFormat: "JWT"}.
+type OpenAPISecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// OpenAPIComponents holds the schemas and security schemes referenced by the operations.
+type OpenAPIComponents struct {
+	Schemas         map[string]*OpenAPISchema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// OpenAPIOperation is a single API operation on a path.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+}
+
+// OpenAPIParameter is a path, query or header parameter of an operation.
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema"`
+}
+
+// OpenAPIRequestBody is the request body of an operation.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema of a request or response body of a given Content-Type.
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+// WithOpenAPI allows you to configure the router to serve the OpenAPI document
+// of its routes, as generated by OpenAPI, and optionally a documentation UI.
+//
+// Default behavior is to not serve any OpenAPI document.
+func WithOpenAPI(config OpenAPIConfig) func(options *Config) {
+	return func(opt *Config) {
+		opt.OpenAPI = &config
+	}
+}
+
+// OpenAPI generates the OpenAPI 3.1 document of the routes of the router
+// described by their RouteOptions. The routes that are not registered through
+// a Router, like the health checks and the profiler, are not documented.
+//
+// Every operation documents its errors as the default response, in the
+// format the router responds them.
+func (r *Router) OpenAPI(config OpenAPIConfig) (*OpenAPI, error) {
+	routes, err := r.Routes()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &OpenAPI{
+		OpenAPI: _openAPIVersion,
+		Info:    config.Info,
+		Servers: config.Servers,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+		Components: OpenAPIComponents{
+			SecuritySchemes: config.SecuritySchemes,
+		},
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = _defaultOpenAPITitle
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = _defaultOpenAPIVersion
+	}
+
+	g := newSchemaGenerator()
+	errResponse := r.errorResponse(g)
+
+	for _, route := range routes {
+		h, ok := route.Handler.(*routeHandler)
+		if !ok || strings.HasSuffix(route.Route, "*") {
+			continue
+		}
+
+		path, pathParams := openAPIPath(route.Route)
+		op := newOperation(g, route.Options, pathParams)
+		op.Deprecated = h.version != nil && h.version.options.Deprecated
+		op.Responses["default"] = errResponse
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+
+	doc.Components.Schemas = g.schemas
+
+	return doc, nil
+}
+
+func newOperation(g *schemaGenerator, opts RouteOptions, pathParams []string) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		OperationID: opts.OperationID,
+		Summary:     opts.Summary,
+		Description: opts.Description,
+		Tags:        opts.Tags,
+		Responses:   make(map[string]OpenAPIResponse),
+	}
+
+	if opts.Request != nil {
+		op.Parameters, op.RequestBody = g.request(reflect.TypeOf(opts.Request))
+	}
+
+	// Path parameters are required, and documented even if not bound.
+	for _, name := range pathParams {
+		found := false
+		for i, p := range op.Parameters {
+			if p.In == _tagPath && p.Name == name {
+				op.Parameters[i].Required = true
+				found = true
+			}
+		}
+		if !found {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:     name,
+				In:       _tagPath,
+				Required: true,
+				Schema:   &OpenAPISchema{Type: "string"},
+			})
+		}
+	}
+
+	for status, body := range opts.Responses {
+		resp := OpenAPIResponse{Description: http.StatusText(status)}
+		if body != nil {
+			resp.Content = map[string]OpenAPIMediaType{
+				_mimeApplicationJSON: {Schema: g.schema(reflect.TypeOf(body))},
+			}
+		}
+		op.Responses[strconv.Itoa(status)] = resp
+	}
+	if len(opts.Responses) == 0 {
+		op.Responses[strconv.Itoa(http.StatusOK)] = OpenAPIResponse{Description: http.StatusText(http.StatusOK)}
+	}
+
+	for _, scheme := range opts.Security {
+		op.Security = append(op.Security, map[string][]string{scheme: {}})
+	}
+
+	return op
+}
+
+// errorResponse documents the errors in the format the router responds them.
+func (r *Router) errorResponse(g *schemaGenerator) OpenAPIResponse {
+	if r.problemDetails {
+		g.schemas["Problem"] = &OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*OpenAPISchema{
+				"type":     {Type: "string", Format: "uri-reference"},
+				"title":    {Type: "string"},
+				"status":   {Type: "integer"},
+				"detail":   {Type: "string"},
+				"instance": {Type: "string", Format: "uri-reference"},
+			},
+			Required: []string{"type", "title", "status"},
+		}
+
+		return OpenAPIResponse{
+			Description: "Error",
+			Content: map[string]OpenAPIMediaType{
+				_mimeApplicationProblemJSON: {Schema: &OpenAPISchema{Ref: _openAPIComponentPrefix + "Problem"}},
+			},
+		}
+	}
+
+	return OpenAPIResponse{
+		Description: "Error",
+		Content: map[string]OpenAPIMediaType{
+			_mimeApplicationJSON: {Schema: g.schema(reflect.TypeOf(Error{}))},
+		},
+	}
+}
+
+// openAPIPath converts a chi route pattern into an OpenAPI path, stripping
+// the regular expressions of its parameters, and returns their names.
+func openAPIPath(pattern string) (string, []string) {
+	var b strings.Builder
+	var params []string
+
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '{' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+
+		// Regular expressions may contain braces themselves, like {id:[0-9]{4}}.
+		depth, end := 0, len(pattern)
+		for j := i; j < len(pattern); j++ {
+			if pattern[j] == '{' {
+				depth++
+			} else if pattern[j] == '}' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+
+		name, _, _ := strings.Cut(pattern[i+1:min(end, len(pattern))], ":")
+		params = append(params, name)
+		b.WriteString("{" + name + "}")
+		i = end
+	}
+
+	return b.String(), params
+}
+
+var _docsTemplate = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="{{.AssetsURL}}/swagger-ui.css" crossorigin>
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.AssetsURL}}/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({url: {{.SpecURL}}, dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`))
+
+// serveOpenAPI registers on mux the routes that serve the OpenAPI document of
+// r and its documentation UI.
+func (r *Router) serveOpenAPI(config OpenAPIConfig) {
+	if config.Path == "" {
+		config.Path = _defaultOpenAPIPath
+	}
+
+	r.mux.Get(config.Path, func(w http.ResponseWriter, req *http.Request) {
+		doc, err := r.OpenAPI(config)
+		if err != nil {
+			_ = RespondJSON(w, http.StatusInternalServerError, WrapError(http.StatusInternalServerError, err))
+			return
+		}
+		_ = RespondJSON(w, http.StatusOK, doc)
+	})
+
+	if config.DocsPath == "" {
+		return
+	}
+
+	//revive:disable:unused-parameter
+	r.mux.Get(config.DocsPath, func(w http.ResponseWriter, req *http.Request) {
+		title := config.Info.Title
+		if title == "" {
+			title = _defaultOpenAPITitle
+		}
+
+		assetsURL := strings.TrimSuffix(config.DocsAssetsURL, "/")
+		if assetsURL == "" {
+			assetsURL = _defaultDocsAssetsURL
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_ = _docsTemplate.Execute(w, struct{ Title, SpecURL, AssetsURL string }{
+			Title:     title,
+			SpecURL:   config.Path,
+			AssetsURL: assetsURL,
+		})
+	})
+	//revive:enable:unused-parameter
+}
@@ -0,0 +1,369 @@
+package httprouter
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	_timeType          = reflect.TypeOf(time.Time{})
+	_rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	_textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	_invalidSchemaName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+// OpenAPISchema is a JSON Schema describing a parameter or a body.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Enum                 []any                     `json:"enum,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64                  `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64                  `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+}
+
+// schemaGenerator reflects Go types into schemas, registering the named
+// structs as components referenced by the schemas.
+type schemaGenerator struct {
+	schemas map[string]*OpenAPISchema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*OpenAPISchema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// request documents the parameters and the body bound from t by Bind.
+func (g *schemaGenerator) request(t reflect.Type) ([]OpenAPIParameter, *OpenAPIRequestBody) {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct || isScalarType(t) {
+		return nil, &OpenAPIRequestBody{
+			Required: true,
+			Content:  map[string]OpenAPIMediaType{_mimeApplicationJSON: {Schema: g.schema(t)}},
+		}
+	}
+
+	var params []OpenAPIParameter
+	form := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	formType := _mimeApplicationForm
+	hasBody := false
+
+	for _, field := range structFields(t) {
+		source, name := paramTag(field)
+		switch source {
+		case "":
+			hasBody = hasBody || !jsonIgnored(field)
+		case _tagForm:
+			if isFileField(field.Type) {
+				formType = _mimeMultipartForm
+			}
+			s := g.schema(field.Type)
+			if applyValidation(s, field.Type, field.Tag.Get("validate")) {
+				form.Required = append(form.Required, name)
+			}
+			form.Properties[name] = s
+		default:
+			s := g.schema(field.Type)
+			params = append(params, OpenAPIParameter{
+				Name:     name,
+				In:       source,
+				Required: applyValidation(s, field.Type, field.Tag.Get("validate")),
+				Schema:   s,
+			})
+		}
+	}
+
+	switch {
+	case len(form.Properties) > 0:
+		return params, &OpenAPIRequestBody{
+			Required: len(form.Required) > 0,
+			Content:  map[string]OpenAPIMediaType{formType: {Schema: form}},
+		}
+	case hasBody:
+		s := g.schema(t)
+		return params, &OpenAPIRequestBody{
+			Required: len(g.resolve(s).Required) > 0,
+			Content:  map[string]OpenAPIMediaType{_mimeApplicationJSON: {Schema: s}},
+		}
+	default:
+		return params, nil
+	}
+}
+
+// schema returns the schema of t, a reference for named structs.
+func (g *schemaGenerator) schema(t reflect.Type) *OpenAPISchema {
+	t = indirectType(t)
+
+	switch t {
+	case _timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case _durationType:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case _rawMessageType:
+		return &OpenAPISchema{}
+	case _fileHeaderType.Elem():
+		return &OpenAPISchema{Type: "string", Format: "binary"}
+	}
+
+	if reflect.PointerTo(t).Implements(_textMarshalerType) {
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte"}
+		}
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.ref(t)
+	default:
+		return &OpenAPISchema{}
+	}
+}
+
+// ref registers the schema of the named struct t as a component and returns
+// a reference to it.
+func (g *schemaGenerator) ref(t reflect.Type) *OpenAPISchema {
+	name, ok := g.names[t]
+	if !ok {
+		name = _invalidSchemaName.ReplaceAllString(t.Name(), "_")
+		for i := 2; g.schemas[name] != nil; i++ {
+			name = _invalidSchemaName.ReplaceAllString(t.Name(), "_") + strconv.Itoa(i)
+		}
+
+		// The name is registered first, so recursive types reference it.
+		g.names[t] = name
+		g.schemas[name] = &OpenAPISchema{}
+		*g.schemas[name] = *g.object(t)
+	}
+
+	return &OpenAPISchema{Ref: _openAPIComponentPrefix + name}
+}
+
+// resolve returns the component referenced by s, if any.
+func (g *schemaGenerator) resolve(s *OpenAPISchema) *OpenAPISchema {
+	if name, ok := strings.CutPrefix(s.Ref, _openAPIComponentPrefix); ok {
+		return g.schemas[name]
+	}
+	return s
+}
+
+// object returns the schema of the JSON object encoded from the struct t,
+// leaving out the fields bound from the parameters of the request.
+func (g *schemaGenerator) object(t reflect.Type) *OpenAPISchema {
+	s := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+
+	for _, field := range structFields(t) {
+		if source, _ := paramTag(field); source != "" || jsonIgnored(field) {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		fs := g.schema(field.Type)
+		if opts == "string" {
+			fs = &OpenAPISchema{Type: "string"}
+		}
+		if applyValidation(fs, field.Type, field.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = fs
+	}
+
+	return s
+}
+
+// structFields returns the exported fields of t, promoting the fields of the
+// embedded structs encoded by encoding/json.
+func structFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && field.Anonymous {
+			continue
+		}
+
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			fields = append(fields, structFields(indirectType(field.Type))...)
+			continue
+		}
+
+		if field.IsExported() {
+			fields = append(fields, field)
+		}
+	}
+
+	return fields
+}
+
+// paramTag returns the source and the name of the field when it is bound
+// from a parameter of the request rather than from the JSON body, with the
+// precedence of paramValues.
+func paramTag(field reflect.StructField) (string, string) {
+	for _, tag := range _paramTags {
+		if name := field.Tag.Get(tag); name != "" {
+			return tag, name
+		}
+	}
+
+	return "", ""
+}
+
+// jsonIgnored reports whether encoding/json ignores the field.
+func jsonIgnored(field reflect.StructField) bool {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name == "-"
+}
+
+// applyValidation adds to s the constraints of the validate tag of a field of
+// type t, and reports whether the field is required.
+func applyValidation(s *OpenAPISchema, t reflect.Type, tag string) bool {
+	t = indirectType(t)
+	required := false
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if name == "dive" {
+			break
+		}
+
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			s.Format = "email"
+		case "url", "uri", "http_url":
+			s.Format = "uri"
+		case "uuid", "uuid4", "uuid_rfc4122", "uuid4_rfc4122":
+			s.Format = "uuid"
+		case "ipv4":
+			s.Format = "ipv4"
+		case "ipv6":
+			s.Format = "ipv6"
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, enumValue(t, v))
+			}
+		case "min", "gte", "max", "lte", "gt", "lt", "len", "eq":
+			applyBound(s, t, name, param)
+		}
+	}
+
+	return required
+}
+
+func applyBound(s *OpenAPISchema, t reflect.Type, rule, param string) {
+	if s.Ref != "" {
+		return
+	}
+
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		length := int(n)
+		minLen, maxLen := &s.MinLength, &s.MaxLength
+		if t.Kind() != reflect.String {
+			minLen, maxLen = &s.MinItems, &s.MaxItems
+		}
+
+		switch rule {
+		case "min", "gte":
+			*minLen = &length
+		case "gt":
+			length++
+			*minLen = &length
+		case "max", "lte":
+			*maxLen = &length
+		case "lt":
+			length--
+			*maxLen = &length
+		case "len", "eq":
+			*minLen, *maxLen = &length, &length
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch rule {
+		case "min", "gte":
+			s.Minimum = &n
+		case "gt":
+			s.ExclusiveMinimum = &n
+		case "max", "lte":
+			s.Maximum = &n
+		case "lt":
+			s.ExclusiveMaximum = &n
+		case "len", "eq":
+			s.Minimum, s.Maximum = &n, &n
+		}
+	}
+}
+
+// enumValue converts a value of a oneof tag into the JSON type of t.
+func enumValue(t reflect.Type, v string) any {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isScalarType reports whether the struct t is encoded as a JSON scalar.
+func isScalarType(t reflect.Type) bool {
+	return t == _timeType || reflect.PointerTo(t).Implements(_textMarshalerType)
+}
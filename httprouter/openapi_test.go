@@ -0,0 +1,239 @@
+package httprouter_test
+
+import (
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+type openAPIAddress struct {
+	Street string `json:"street" validate:"required"`
+}
+
+type openAPICard struct {
+	ID        string          `json:"id"`
+	Status    string          `json:"status" validate:"oneof=ACTIVE BLOCKED"`
+	CreatedAt time.Time       `json:"created_at"`
+	Address   *openAPIAddress `json:"address,omitempty"`
+	Related   []openAPICard   `json:"related,omitempty"`
+}
+
+type openAPIUpdateCard struct {
+	ID        string `path:"id"`
+	DryRun    bool   `query:"dry_run"`
+	RequestID string `header:"X-Request-Id" validate:"required,uuid"`
+	Alias     string `json:"alias" validate:"required,min=3,max=20"`
+	Limit     int    `json:"limit" validate:"gte=0,lt=1000"`
+}
+
+type openAPIUpload struct {
+	Name string                `form:"name" validate:"required"`
+	File *multipart.FileHeader `form:"file"`
+}
+
+func TestRouterOpenAPI(t *testing.T) {
+	r := httprouter.New(httprouter.WithOpenAPI(httprouter.OpenAPIConfig{
+		DocsPath: "/docs",
+		Info:     httprouter.OpenAPIInfo{Title: "Cards", Version: "2.0.0"},
+		SecuritySchemes: map[string]httprouter.OpenAPISecurityScheme{
+			"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+		},
+	}))
+
+	h := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+
+	r.Route("/cards", func(r httprouter.Router) {
+		r.Patch("/{id:[a-z]{3}-[0-9]+}", h,
+			httprouter.WithOperationID("updateCard"),
+			httprouter.WithSummary("Update a card"),
+			httprouter.WithTags("cards"),
+			httprouter.WithRequest(openAPIUpdateCard{}),
+			httprouter.WithResponse(http.StatusOK, openAPICard{}),
+			httprouter.WithResponse(http.StatusNoContent, nil),
+			httprouter.WithSecurity("bearer"),
+		)
+		r.Post("/{id}/documents", h, httprouter.WithRequest(openAPIUpload{}))
+	})
+	r.Get("/ping", h)
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/openapi.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var doc httprouter.OpenAPI
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&doc))
+
+	assert.Equal(t, "3.1.0", doc.OpenAPI)
+	assert.Equal(t, httprouter.OpenAPIInfo{Title: "Cards", Version: "2.0.0"}, doc.Info)
+	assert.Len(t, doc.Paths, 3)
+	assert.Contains(t, doc.Components.SecuritySchemes, "bearer")
+
+	t.Run("operation", func(t *testing.T) {
+		op := doc.Paths["/cards/{id}"]["patch"]
+		require.NotNil(t, op)
+
+		assert.Equal(t, "updateCard", op.OperationID)
+		assert.Equal(t, "Update a card", op.Summary)
+		assert.Equal(t, []string{"cards"}, op.Tags)
+		assert.Equal(t, []map[string][]string{{"bearer": {}}}, op.Security)
+
+		assert.Equal(t, []httprouter.OpenAPIParameter{
+			{Name: "id", In: "path", Required: true, Schema: &httprouter.OpenAPISchema{Type: "string"}},
+			{Name: "dry_run", In: "query", Schema: &httprouter.OpenAPISchema{Type: "boolean"}},
+			{Name: "X-Request-Id", In: "header", Required: true, Schema: &httprouter.OpenAPISchema{Type: "string", Format: "uuid"}},
+		}, op.Parameters)
+
+		require.NotNil(t, op.RequestBody)
+		assert.True(t, op.RequestBody.Required)
+		assert.Equal(t, "#/components/schemas/openAPIUpdateCard", op.RequestBody.Content["application/json"].Schema.Ref)
+
+		assert.Equal(t, "#/components/schemas/openAPICard", op.Responses["200"].Content["application/json"].Schema.Ref)
+		assert.Equal(t, httprouter.OpenAPIResponse{Description: "No Content"}, op.Responses["204"])
+		assert.Equal(t, "#/components/schemas/Error", op.Responses["default"].Content["application/json"].Schema.Ref)
+	})
+
+	t.Run("schemas", func(t *testing.T) {
+		minLength, maxLength := 3, 20
+		minimum, exclusiveMaximum := 0.0, 1000.0
+		assert.Equal(t, &httprouter.OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*httprouter.OpenAPISchema{
+				"alias": {Type: "string", MinLength: &minLength, MaxLength: &maxLength},
+				"limit": {Type: "integer", Format: "int64", Minimum: &minimum, ExclusiveMaximum: &exclusiveMaximum},
+			},
+			Required: []string{"alias"},
+		}, doc.Components.Schemas["openAPIUpdateCard"])
+
+		assert.Equal(t, &httprouter.OpenAPISchema{
+			Type: "object",
+			Properties: map[string]*httprouter.OpenAPISchema{
+				"id":         {Type: "string"},
+				"status":     {Type: "string", Enum: []any{"ACTIVE", "BLOCKED"}},
+				"created_at": {Type: "string", Format: "date-time"},
+				"address":    {Ref: "#/components/schemas/openAPIAddress"},
+				"related":    {Type: "array", Items: &httprouter.OpenAPISchema{Ref: "#/components/schemas/openAPICard"}},
+			},
+		}, doc.Components.Schemas["openAPICard"])
+
+		assert.Equal(t, []string{"street"}, doc.Components.Schemas["openAPIAddress"].Required)
+	})
+
+	t.Run("form", func(t *testing.T) {
+		op := doc.Paths["/cards/{id}/documents"]["post"]
+		require.NotNil(t, op)
+
+		assert.Equal(t, &httprouter.OpenAPIRequestBody{
+			Required: true,
+			Content: map[string]httprouter.OpenAPIMediaType{
+				"multipart/form-data": {Schema: &httprouter.OpenAPISchema{
+					Type: "object",
+					Properties: map[string]*httprouter.OpenAPISchema{
+						"name": {Type: "string"},
+						"file": {Type: "string", Format: "binary"},
+					},
+					Required: []string{"name"},
+				}},
+			},
+		}, op.RequestBody)
+	})
+
+	t.Run("undocumented route", func(t *testing.T) {
+		op := doc.Paths["/ping"]["get"]
+		require.NotNil(t, op)
+
+		assert.Equal(t, httprouter.OpenAPIResponse{Description: "OK"}, op.Responses["200"])
+		assert.Nil(t, op.Parameters)
+		assert.Nil(t, op.RequestBody)
+	})
+
+	t.Run("docs", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/docs")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `src="https://unpkg.com/swagger-ui-dist@5.17.14/swagger-ui-bundle.js"`)
+	})
+}
+
+func TestRouterOpenAPI_DocsAssetsURL(t *testing.T) {
+	r := httprouter.New(httprouter.WithOpenAPI(httprouter.OpenAPIConfig{
+		DocsPath:      "/docs",
+		DocsAssetsURL: "/static/swagger-ui/",
+	}))
+
+	recorder := httptest.NewRecorder()
+	r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `href="/static/swagger-ui/swagger-ui.css"`)
+	assert.Contains(t, recorder.Body.String(), `src="/static/swagger-ui/swagger-ui-bundle.js"`)
+	assert.NotContains(t, recorder.Body.String(), "unpkg.com")
+}
+
+func TestRouterOpenAPI_ProblemDetails(t *testing.T) {
+	r := httprouter.New(httprouter.WithProblemDetails(true))
+	r.Get("/ping", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	doc, err := r.OpenAPI(httprouter.OpenAPIConfig{})
+	require.NoError(t, err)
+
+	assert.Equal(t, httprouter.OpenAPIInfo{Title: "API", Version: "1.0.0"}, doc.Info)
+	errResp := doc.Paths["/ping"]["get"].Responses["default"]
+	assert.Equal(t, "#/components/schemas/Problem", errResp.Content["application/problem+json"].Schema.Ref)
+	assert.Contains(t, doc.Components.Schemas, "Problem")
+}
+
+type openAPIListMovements struct {
+	CardID string `json:"id" path:"id"`
+	Page   int    `json:"-" query:"page" validate:"min=1"`
+	Secret string `json:"-"`
+	Since  string `json:"since"`
+}
+
+func TestRouterOpenAPI_ParamTags(t *testing.T) {
+	r := httprouter.New()
+	r.Post("/cards/{id}/movements", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}, httprouter.WithRequest(openAPIListMovements{}))
+
+	doc, err := r.OpenAPI(httprouter.OpenAPIConfig{})
+	require.NoError(t, err)
+
+	op := doc.Paths["/cards/{id}/movements"]["post"]
+	require.NotNil(t, op)
+
+	minimum := 1.0
+	assert.Equal(t, []httprouter.OpenAPIParameter{
+		{Name: "id", In: "path", Required: true, Schema: &httprouter.OpenAPISchema{Type: "string"}},
+		{Name: "page", In: "query", Schema: &httprouter.OpenAPISchema{Type: "integer", Format: "int64", Minimum: &minimum}},
+	}, op.Parameters)
+
+	assert.Equal(t, &httprouter.OpenAPISchema{
+		Type: "object",
+		Properties: map[string]*httprouter.OpenAPISchema{
+			"since": {Type: "string"},
+		},
+	}, doc.Components.Schemas["openAPIListMovements"])
+}
@@ -0,0 +1,144 @@
+package httprouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	_mimeApplicationProblemJSON = "application/problem+json"
+
+	// _problemTypeBlank is the default problem type, which indicates that the
+	// problem has no additional semantics beyond that of the HTTP status code.
+	_problemTypeBlank = "about:blank"
+)
+
+// Problem is a Problem Details object as defined by RFC 9457.
+type Problem struct {
+	// Type is a URI reference that identifies the problem type.
+	Type string
+	// Title is a short, human-readable summary of the problem type.
+	Title string
+	// Status is the HTTP status code of the response.
+	Status int
+	// Detail is a human-readable explanation specific to this occurrence of the problem.
+	Detail string
+	// Instance is a URI reference that identifies the specific occurrence of the problem.
+	Instance string
+	// Extensions are additional members of the problem. Members named like the
+	// ones above are ignored.
+	Extensions map[string]any
+}
+
+// NewProblem creates the Problem Details object for the given error and request.
+//
+// Errors other than *Error are converted as DefaultHandlerError does. The
+// message of the error becomes the detail of the problem, while its business
+// code, details, violations and extensions become extension members, along
+// with the "trace_id" of the span of the request, if any.
+func NewProblem(r *http.Request, err error) Problem {
+	webErr, ok := err.(*Error)
+	if !ok {
+		webErr, _ = DefaultHandlerError(err).Error.(*Error)
+	}
+
+	problem := Problem{
+		Type:       webErr.Type,
+		Title:      http.StatusText(webErr.StatusCode),
+		Status:     webErr.StatusCode,
+		Detail:     webErr.Message,
+		Instance:   r.URL.Path,
+		Extensions: make(map[string]any, len(webErr.Extensions)+4),
+	}
+	if problem.Type == "" {
+		problem.Type = _problemTypeBlank
+	}
+
+	for k, v := range webErr.Extensions {
+		problem.Extensions[k] = v
+	}
+	if webErr.Code != statusCodeText(webErr.StatusCode) {
+		problem.Extensions["code"] = webErr.Code
+	}
+	if len(webErr.Details) > 0 {
+		problem.Extensions["details"] = webErr.Details
+	}
+	if len(webErr.Violations) > 0 {
+		problem.Extensions["violations"] = webErr.Violations
+	}
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		problem.Extensions["trace_id"] = sc.TraceID().String()
+	}
+
+	return problem
+}
+
+// MarshalJSON encodes the problem as a single JSON object holding both the
+// standard and the extension members.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+
+	return json.Marshal(members)
+}
+
+// RespondProblem sends the Problem Details object of err to the client as
+// "application/problem+json".
+func RespondProblem(w http.ResponseWriter, r *http.Request, err error) error {
+	return writeProblem(w, NewProblem(r, err))
+}
+
+// problemOf creates the Problem Details object of the error returned by an
+// ErrorHandlerFunc, responded with its status code.
+func problemOf(r *http.Request, handleErr HandlerError) Problem {
+	var problem Problem
+	switch v := handleErr.Error.(type) {
+	case Problem:
+		problem = v
+	case *Problem:
+		problem = *v
+	case *Error:
+		problem = NewProblem(r, v)
+	case Error:
+		problem = NewProblem(r, &v)
+	case error:
+		problem = NewProblem(r, NewError(handleErr.StatusCode, v.Error()))
+	default:
+		problem = NewProblem(r, NewError(handleErr.StatusCode, fmt.Sprint(v)))
+	}
+
+	if problem.Status != handleErr.StatusCode {
+		problem.Status = handleErr.StatusCode
+		problem.Title = http.StatusText(handleErr.StatusCode)
+	}
+
+	return problem
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) error {
+	b, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", _mimeApplicationProblemJSON)
+	w.WriteHeader(problem.Status)
+	_, err = w.Write(b)
+
+	return err
+}
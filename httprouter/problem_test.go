@@ -0,0 +1,126 @@
+package httprouter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func TestProblemDetails(t *testing.T) {
+	conflict := func(w http.ResponseWriter, r *http.Request) error {
+		return httprouter.NewError(http.StatusConflict, "card already exists").(*httprouter.Error).
+			WithType("https://developers.pomelo.la/problems/card-exists").
+			WithExtension("card_id", "crd-1").
+			WithCode("card_already_exists").
+			WithDetail("brand", "visa")
+	}
+	failure := func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("database is down")
+	}
+	validate := func(w http.ResponseWriter, r *http.Request) error {
+		var payload struct {
+			Brand string `json:"brand" validate:"required"`
+		}
+		return httprouter.Bind(r, &payload)
+	}
+
+	problems := httprouter.New(httprouter.WithProblemDetails(true))
+	problems.Post("/cards", conflict)
+	problems.With().Get("/failure", failure)
+	problems.Route("/v2", func(r httprouter.Router) {
+		r.Post("/cards", validate)
+	})
+
+	legacy := httprouter.New()
+	legacy.Post("/cards", conflict)
+
+	tests := []struct {
+		name                string
+		handler             http.Handler
+		method              string
+		target              string
+		body                string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "renders type and extensions",
+			handler:             problems,
+			method:              http.MethodPost,
+			target:              "/cards",
+			expectedCode:        http.StatusConflict,
+			expectedContentType: "application/problem+json",
+			expectedBody: `{"type":"https://developers.pomelo.la/problems/card-exists","title":"Conflict","status":409,` +
+				`"detail":"card already exists","instance":"/cards","card_id":"crd-1","code":"card_already_exists","details":{"brand":"visa"}}`,
+		},
+		{
+			name:                "converts unknown errors",
+			handler:             problems,
+			method:              http.MethodGet,
+			target:              "/failure",
+			expectedCode:        http.StatusInternalServerError,
+			expectedContentType: "application/problem+json",
+			expectedBody: `{"type":"about:blank","title":"Internal Server Error","status":500,` +
+				`"detail":"database is down","instance":"/failure"}`,
+		},
+		{
+			name:                "renders violations in subrouters",
+			handler:             problems,
+			method:              http.MethodPost,
+			target:              "/v2/cards",
+			body:                `{}`,
+			expectedCode:        http.StatusUnprocessableEntity,
+			expectedContentType: "application/problem+json",
+			expectedBody: `{"type":"about:blank","title":"Unprocessable Entity","status":422,` +
+				`"detail":"validation_error: invalid fields: Brand","instance":"/v2/cards",` +
+				`"violations":[{"field":"brand","tag":"required","value":"","message":"brand is a required field"}]}`,
+		},
+		{
+			name:                "keeps legacy format",
+			handler:             legacy,
+			method:              http.MethodPost,
+			target:              "/cards",
+			expectedCode:        http.StatusConflict,
+			expectedContentType: "application/json",
+			expectedBody:        `{"message":"card already exists","error":"card_already_exists","status":409,"details":{"brand":"visa"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			res := httptest.NewRecorder()
+
+			tt.handler.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.expectedCode, res.Code)
+			assert.Equal(t, tt.expectedContentType, res.Header().Get("Content-Type"))
+			assert.JSONEq(t, tt.expectedBody, res.Body.String())
+		})
+	}
+}
+
+func TestNewProblem_TraceID(t *testing.T) {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x01},
+		SpanID:  trace.SpanID{0x01},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	req := httptest.NewRequest(http.MethodGet, "/cards/1", nil).WithContext(ctx)
+
+	problem := httprouter.NewProblem(req, httprouter.NewError(http.StatusNotFound, "card not found"))
+
+	require.Equal(t, sc.TraceID().String(), problem.Extensions["trace_id"])
+	require.Equal(t, "Not Found", problem.Title)
+	require.Equal(t, "/cards/1", problem.Instance)
+}
@@ -0,0 +1,238 @@
+package httprouter
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// _sweepInterval is the minimum time between two sweeps of expired keys in
+// the MemoryRateLimitStore.
+const _sweepInterval = time.Minute
+
+// RateLimitResult is the outcome of taking a request from the quota of a key.
+type RateLimitResult struct {
+	// Allowed reports whether the request is within the quota.
+	Allowed bool
+	// Limit is the maximum number of requests of the quota.
+	Limit int
+	// Remaining is the number of requests left in the quota.
+	Remaining int
+	// Reset is the time until the quota is fully restored.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, only set
+	// when the request is not allowed.
+	RetryAfter time.Duration
+}
+
+// RateLimitState is the state of a rate limited key. It is shared by all the
+// algorithms so that a RateLimitStore can persist it regardless of the
+// algorithm in use.
+type RateLimitState struct {
+	// Tokens is the number of tokens left in a token bucket.
+	Tokens float64 `json:"tokens,omitempty"`
+	// Count is the number of requests in the current sliding window.
+	Count int `json:"count,omitempty"`
+	// PrevCount is the number of requests in the previous sliding window.
+	PrevCount int `json:"prev_count,omitempty"`
+	// Timestamp is the time of the last refill of a token bucket or the
+	// start of the current sliding window.
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RateLimitAlgorithm decides whether a request is allowed given the state of
+// its key.
+type RateLimitAlgorithm interface {
+	// Take consumes a request from the quota held in state, modifying it in place.
+	Take(state *RateLimitState, now time.Time) RateLimitResult
+	// TTL is the time after which an untouched state is equivalent to the zero state.
+	TTL() time.Duration
+	// Policy describes the quota as in the RateLimit-Policy header, e.g. "100;w=60".
+	Policy() string
+}
+
+// RateLimitStore persists the state of every rate limited key.
+//
+// Implementations backed by shared storage allow several instances of a
+// service to enforce a single quota. Update must be atomic: concurrent calls
+// for the same key must observe each other's modifications.
+type RateLimitStore interface {
+	// Update applies fn to the state stored under key and persists it for at
+	// least ttl. Missing or expired keys are given to fn as the zero state.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state *RateLimitState)) error
+}
+
+// TokenBucket returns a RateLimitAlgorithm that refills rate tokens every per
+// duration up to burst tokens, every request consuming a single token.
+func TokenBucket(rate int, per time.Duration, burst int) RateLimitAlgorithm {
+	return tokenBucket{
+		rate:  float64(rate) / float64(per),
+		burst: burst,
+	}
+}
+
+type tokenBucket struct {
+	// rate is expressed in tokens per nanosecond.
+	rate  float64
+	burst int
+}
+
+func (tb tokenBucket) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	if state.Timestamp.IsZero() {
+		state.Tokens = float64(tb.burst)
+	} else if elapsed := now.Sub(state.Timestamp); elapsed > 0 {
+		state.Tokens = math.Min(float64(tb.burst), state.Tokens+float64(elapsed)*tb.rate)
+	}
+	state.Timestamp = now
+
+	result := RateLimitResult{Limit: tb.burst}
+	if state.Tokens >= 1 {
+		state.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - state.Tokens) / tb.rate))
+	}
+
+	result.Remaining = int(state.Tokens)
+	result.Reset = time.Duration(math.Ceil((float64(tb.burst) - state.Tokens) / tb.rate))
+
+	return result
+}
+
+func (tb tokenBucket) TTL() time.Duration {
+	return time.Duration(math.Ceil(float64(tb.burst) / tb.rate))
+}
+
+func (tb tokenBucket) Policy() string {
+	return policy(tb.burst, tb.TTL())
+}
+
+// SlidingWindow returns a RateLimitAlgorithm that allows up to limit requests
+// in any window of the given duration. The count of the previous window is
+// weighted by its overlap with the sliding window.
+func SlidingWindow(limit int, window time.Duration) RateLimitAlgorithm {
+	return slidingWindow{
+		limit:  limit,
+		window: window,
+	}
+}
+
+type slidingWindow struct {
+	limit  int
+	window time.Duration
+}
+
+func (sw slidingWindow) Take(state *RateLimitState, now time.Time) RateLimitResult {
+	start := now.Truncate(sw.window)
+	if !state.Timestamp.Equal(start) {
+		if state.Timestamp.Equal(start.Add(-sw.window)) {
+			state.PrevCount = state.Count
+		} else {
+			state.PrevCount = 0
+		}
+		state.Count = 0
+		state.Timestamp = start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - float64(elapsed)/float64(sw.window)
+	estimate := float64(state.PrevCount)*weight + float64(state.Count)
+
+	result := RateLimitResult{
+		Limit: sw.limit,
+		Reset: sw.window - elapsed,
+	}
+
+	if estimate+1 > float64(sw.limit) {
+		result.RetryAfter = sw.retryAfter(state, elapsed)
+		result.Remaining = 0
+		return result
+	}
+
+	state.Count++
+	result.Allowed = true
+	result.Remaining = int(float64(sw.limit) - estimate - 1)
+
+	return result
+}
+
+// retryAfter computes the time until the weighted count of the previous
+// window decreases enough to let another request through.
+func (sw slidingWindow) retryAfter(state *RateLimitState, elapsed time.Duration) time.Duration {
+	if state.Count+1 > sw.limit || state.PrevCount == 0 {
+		return sw.window - elapsed
+	}
+
+	// prev * (1 - t/window) + count + 1 <= limit
+	t := float64(sw.window) * (1 - float64(sw.limit-state.Count-1)/float64(state.PrevCount))
+
+	return time.Duration(math.Ceil(t)) - elapsed
+}
+
+func (sw slidingWindow) TTL() time.Duration {
+	return 2 * sw.window
+}
+
+func (sw slidingWindow) Policy() string {
+	return policy(sw.limit, sw.window)
+}
+
+// MemoryRateLimitStore is an in-process RateLimitStore. It is suitable for a
+// single instance, or when every instance may enforce its own quota.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryRateLimitEntry
+	lastSweep time.Time
+}
+
+type memoryRateLimitEntry struct {
+	state     RateLimitState
+	expiresAt time.Time
+}
+
+// NewMemoryRateLimitStore instantiates an empty MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries:   make(map[string]memoryRateLimitEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Update applies fn to the state stored under key and persists it for ttl.
+//
+//revive:disable:unused-parameter
+func (s *MemoryRateLimitStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state *RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = memoryRateLimitEntry{}
+	}
+
+	fn(&entry.state)
+	entry.expiresAt = now.Add(ttl)
+	s.entries[key] = entry
+
+	return nil
+}
+
+//revive:enable:unused-parameter
+
+// sweep evicts the expired keys at most once every _sweepInterval.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < _sweepInterval {
+		return
+	}
+
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
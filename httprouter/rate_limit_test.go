@@ -0,0 +1,95 @@
+package httprouter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func TestTokenBucket(t *testing.T) {
+	tb := httprouter.TokenBucket(1, time.Second, 2)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var state httprouter.RateLimitState
+
+	res := tb.Take(&state, now)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 2, res.Limit)
+	assert.Equal(t, 1, res.Remaining)
+
+	res = tb.Take(&state, now)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+	assert.Equal(t, 2*time.Second, res.Reset)
+
+	res = tb.Take(&state, now.Add(500*time.Millisecond))
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	res = tb.Take(&state, now.Add(time.Second))
+	assert.True(t, res.Allowed)
+
+	assert.Equal(t, 2*time.Second, tb.TTL())
+	assert.Equal(t, "2;w=2", tb.Policy())
+}
+
+func TestSlidingWindow(t *testing.T) {
+	sw := httprouter.SlidingWindow(4, time.Minute)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var state httprouter.RateLimitState
+	for i := 0; i < 4; i++ {
+		res := sw.Take(&state, start.Add(10*time.Second))
+		require.True(t, res.Allowed)
+		assert.Equal(t, 3-i, res.Remaining)
+	}
+
+	res := sw.Take(&state, start.Add(10*time.Second))
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 50*time.Second, res.RetryAfter)
+	assert.Equal(t, 50*time.Second, res.Reset)
+
+	// A quarter into the next window, the previous count weighs 3 requests.
+	res = sw.Take(&state, start.Add(75*time.Second))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res = sw.Take(&state, start.Add(75*time.Second))
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 15*time.Second, res.RetryAfter)
+
+	// Windows older than the previous one are discarded.
+	res = sw.Take(&state, start.Add(200*time.Second))
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 3, res.Remaining)
+
+	assert.Equal(t, "4;w=60", sw.Policy())
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := httprouter.NewMemoryRateLimitStore()
+	ctx := context.Background()
+
+	increment := func(state *httprouter.RateLimitState) { state.Count++ }
+
+	require.NoError(t, store.Update(ctx, "a", time.Minute, increment))
+	require.NoError(t, store.Update(ctx, "a", time.Minute, increment))
+	require.NoError(t, store.Update(ctx, "b", time.Minute, increment))
+
+	var count int
+	require.NoError(t, store.Update(ctx, "a", time.Minute, func(state *httprouter.RateLimitState) {
+		count = state.Count
+	}))
+	assert.Equal(t, 2, count)
+
+	require.NoError(t, store.Update(ctx, "expired", -time.Second, increment))
+	require.NoError(t, store.Update(ctx, "expired", time.Minute, func(state *httprouter.RateLimitState) {
+		count = state.Count
+	}))
+	assert.Equal(t, 0, count)
+}
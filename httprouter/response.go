@@ -1,11 +1,198 @@
 package httprouter
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
+	"mime"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
+const _mimeApplicationOctetStream = "application/octet-stream"
+
+// StreamOptions represents the options for configuring a streamed response.
+type StreamOptions struct {
+	ContentType   string
+	ContentLength int64
+	ETag          string
+}
+
+// WithContentType allows you to configure the Content-Type of the streamed
+// response.
+//
+// Default behavior is to use "application/octet-stream", or the type derived
+// from the extension of the file name for attachments.
+func WithContentType(contentType string) func(opts *StreamOptions) {
+	return func(opts *StreamOptions) {
+		opts.ContentType = contentType
+	}
+}
+
+// WithContentLength allows you to configure the Content-Length of the
+// streamed response.
+//
+// Default behavior is to use the length of the body when it has a Len method,
+// like *bytes.Reader, or when it is an io.Seeker, like *os.File.
+func WithContentLength(contentLength int64) func(opts *StreamOptions) {
+	return func(opts *StreamOptions) {
+		opts.ContentLength = contentLength
+	}
+}
+
+// WithETag allows you to configure the ETag of the streamed response, which
+// is answered with http.StatusNotModified when it matches the "If-None-Match"
+// header of the request. The tag must be quoted, like `"v1"` or `W/"v1"`.
+//
+// Default behavior is to send no ETag.
+func WithETag(etag string) func(opts *StreamOptions) {
+	return func(opts *StreamOptions) {
+		opts.ETag = etag
+	}
+}
+
+// Respond encodes value with the encoder that best matches the "Accept"
+// header of the request and sends it to the client. See RegisterEncoder for
+// the supported media types. If value is nil or code is equal to
+// http.StatusNoContent we avoid writing any content to w.
+//
+// It returns an Error with http.StatusNotAcceptable when none of the
+// accepted media types can encode value.
+func Respond(w http.ResponseWriter, r *http.Request, code int, value any) error {
+	if code == http.StatusNoContent || value == nil {
+		w.WriteHeader(code)
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, enc := range negotiate(r.Header.Get("Accept")) {
+		buf.Reset()
+
+		err := enc.encoder.Encode(&buf, value)
+		if errors.Is(err, ErrUnsupportedValue) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		w.Header().Set("Content-Type", enc.contentType)
+		w.WriteHeader(code)
+		_, err = w.Write(buf.Bytes())
+
+		return err
+	}
+
+	return NewErrorf(http.StatusNotAcceptable, "none of the accepted media types can be responded: %s", r.Header.Get("Accept"))
+}
+
+// RespondStream copies body to the client without buffering it.
+//
+//	f, err := os.Open(path)
+//	if err != nil {
+//		return err
+//	}
+//	defer f.Close()
+//
+//	return httprouter.RespondStream(w, r, http.StatusOK, f, httprouter.WithContentType("application/pdf"))
+func RespondStream(w http.ResponseWriter, r *http.Request, code int, body io.Reader, optFns ...func(opts *StreamOptions)) error {
+	opts := StreamOptions{
+		ContentType:   _mimeApplicationOctetStream,
+		ContentLength: contentLength(body),
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.ETag != "" {
+		w.Header().Set("ETag", opts.ETag)
+		if code == http.StatusOK && etagMatch(r.Header.Get("If-None-Match"), opts.ETag) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+
+	w.Header().Set("Content-Type", opts.ContentType)
+	if opts.ContentLength >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(opts.ContentLength, 10))
+	}
+	w.WriteHeader(code)
+
+	if r.Method == http.MethodHead {
+		return nil
+	}
+
+	_, err := io.Copy(w, body)
+	return err
+}
+
+// RespondAttachment streams content to the client as a file download named
+// filename, setting the "Content-Disposition" header.
+func RespondAttachment(w http.ResponseWriter, r *http.Request, filename string, content io.Reader, optFns ...func(opts *StreamOptions)) error {
+	contentType := mime.TypeByExtension(filepath.Ext(filename))
+	if contentType == "" {
+		contentType = _mimeApplicationOctetStream
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	return RespondStream(w, r, http.StatusOK, content, append([]func(opts *StreamOptions){WithContentType(contentType)}, optFns...)...)
+}
+
+// RespondRedirect redirects the client to url, which may be a path relative
+// to the request path, with a redirection status code: 300, 301, 302, 303,
+// 307 or 308.
+func RespondRedirect(w http.ResponseWriter, r *http.Request, code int, url string) error {
+	switch code {
+	case http.StatusMultipleChoices, http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return NewErrorf(http.StatusInternalServerError, "invalid redirect status code: %d", code)
+	}
+
+	http.Redirect(w, r, url, code)
+	return nil
+}
+
+// contentLength returns the length of body, or -1 when it is unknown.
+func contentLength(body io.Reader) int64 {
+	switch b := body.(type) {
+	case interface{ Len() int }:
+		return int64(b.Len())
+	case io.Seeker:
+		current, err := b.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := b.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := b.Seek(current, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - current
+	default:
+		return -1
+	}
+}
+
+// etagMatch reports whether the "If-None-Match" header matches etag, using
+// the weak comparison.
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
 // RespondJSON converts a Go value to JSON and sends it to the client.
 // If value is nil or code is equal to http.StatusNoContent we avoid writing any content to w.
 // HTTP response header with the provided status code is always set.
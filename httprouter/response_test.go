@@ -1,12 +1,18 @@
 package httprouter_test
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/pomelo-la/go-toolkit/httprouter"
 )
@@ -67,3 +73,231 @@ func TestRespondJSON(t *testing.T) {
 		})
 	}
 }
+
+type cardRow struct {
+	ID     string `json:"id" xml:"id" csv:"id" msgpack:"id"`
+	Brand  string `json:"brand" xml:"brand" csv:"brand" msgpack:"brand"`
+	Secret string `json:"-" xml:"-" csv:"-" msgpack:"-"`
+}
+
+func TestRespond(t *testing.T) {
+	cards := []cardRow{{ID: "crd-1", Brand: "visa"}, {ID: "crd-2", Brand: "mastercard"}}
+
+	tests := []struct {
+		name                string
+		accept              string
+		value               any
+		expectedCode        int
+		expectedContentType string
+		assertBody          func(t *testing.T, body []byte)
+	}{
+		{
+			name:                "defaults to json",
+			value:               cards,
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			assertBody: func(t *testing.T, body []byte) {
+				assert.JSONEq(t, `[{"id":"crd-1","brand":"visa"},{"id":"crd-2","brand":"mastercard"}]`, string(body))
+			},
+		},
+		{
+			name:                "honors quality values",
+			accept:              "application/json;q=0.5, application/xml",
+			value:               cardRow{ID: "crd-1", Brand: "visa"},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/xml",
+			assertBody: func(t *testing.T, body []byte) {
+				assert.Equal(t, `<cardRow><id>crd-1</id><brand>visa</brand></cardRow>`, string(body))
+			},
+		},
+		{
+			name:                "encodes csv",
+			accept:              "text/csv",
+			value:               cards,
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			assertBody: func(t *testing.T, body []byte) {
+				assert.Equal(t, "id,brand\ncrd-1,visa\ncrd-2,mastercard\n", string(body))
+			},
+		},
+		{
+			name:                "encodes text",
+			accept:              "text/*",
+			value:               "pong",
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/plain; charset=utf-8",
+			assertBody: func(t *testing.T, body []byte) {
+				assert.Equal(t, "pong", string(body))
+			},
+		},
+		{
+			name:                "encodes msgpack",
+			accept:              "application/msgpack",
+			value:               cardRow{ID: "crd-1", Brand: "visa"},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/msgpack",
+			assertBody: func(t *testing.T, body []byte) {
+				var decoded cardRow
+				require.NoError(t, msgpack.Unmarshal(body, &decoded))
+				assert.Equal(t, cardRow{ID: "crd-1", Brand: "visa"}, decoded)
+			},
+		},
+		{
+			name:                "encodes protobuf",
+			accept:              "application/x-protobuf",
+			value:               wrapperspb.String("crd-1"),
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/x-protobuf",
+			assertBody: func(t *testing.T, body []byte) {
+				var decoded wrapperspb.StringValue
+				require.NoError(t, proto.Unmarshal(body, &decoded))
+				assert.Equal(t, "crd-1", decoded.GetValue())
+			},
+		},
+		{
+			name:                "falls back to the next accepted encoder",
+			accept:              "application/x-protobuf, application/json;q=0.1",
+			value:               cardRow{ID: "crd-1", Brand: "visa"},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			assertBody: func(t *testing.T, body []byte) {
+				assert.JSONEq(t, `{"id":"crd-1","brand":"visa"}`, string(body))
+			},
+		},
+		{
+			name:         "responds not acceptable",
+			accept:       "image/png",
+			value:        cards,
+			expectedCode: http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httprouter.New()
+			r.Get("/cards", func(w http.ResponseWriter, r *http.Request) error {
+				return httprouter.Respond(w, r, http.StatusOK, tt.value)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/cards", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.assertBody != nil {
+				assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+				tt.assertBody(t, w.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	httprouter.RegisterEncoder("application/vnd.pomelo.card", httprouter.EncoderFunc(func(w io.Writer, value any) error {
+		card, ok := value.(cardRow)
+		if !ok {
+			return httprouter.ErrUnsupportedValue
+		}
+		_, err := io.WriteString(w, card.ID+":"+card.Brand)
+		return err
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/cards/crd-1", nil)
+	req.Header.Set("Accept", "application/vnd.pomelo.card")
+	w := httptest.NewRecorder()
+
+	require.NoError(t, httprouter.Respond(w, req, http.StatusOK, cardRow{ID: "crd-1", Brand: "visa"}))
+	assert.Equal(t, "application/vnd.pomelo.card", w.Header().Get("Content-Type"))
+	assert.Equal(t, "crd-1:visa", w.Body.String())
+}
+
+func TestRespondStream(t *testing.T) {
+	tests := []struct {
+		name                  string
+		method                string
+		ifNoneMatch           string
+		body                  io.Reader
+		options               []func(opts *httprouter.StreamOptions)
+		expectedCode          int
+		expectedContentLength string
+		expectedBody          string
+	}{
+		{
+			name:                  "derives the content length",
+			method:                http.MethodGet,
+			body:                  strings.NewReader("statement"),
+			options:               []func(opts *httprouter.StreamOptions){httprouter.WithETag(`"v1"`)},
+			expectedCode:          http.StatusOK,
+			expectedContentLength: "9",
+			expectedBody:          "statement",
+		},
+		{
+			name:         "streams unknown lengths",
+			method:       http.MethodGet,
+			body:         io.MultiReader(strings.NewReader("state"), strings.NewReader("ment")),
+			expectedCode: http.StatusOK,
+			expectedBody: "statement",
+		},
+		{
+			name:         "answers not modified",
+			method:       http.MethodGet,
+			ifNoneMatch:  `"v0", W/"v1"`,
+			body:         strings.NewReader("statement"),
+			options:      []func(opts *httprouter.StreamOptions){httprouter.WithETag(`"v1"`)},
+			expectedCode: http.StatusNotModified,
+		},
+		{
+			name:                  "skips the body of head requests",
+			method:                http.MethodHead,
+			body:                  strings.NewReader("statement"),
+			options:               []func(opts *httprouter.StreamOptions){httprouter.WithContentLength(9)},
+			expectedCode:          http.StatusOK,
+			expectedContentLength: "9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/statements/1", nil)
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			w := httptest.NewRecorder()
+
+			err := httprouter.RespondStream(w, req, http.StatusOK, tt.body, tt.options...)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedContentLength, w.Header().Get("Content-Length"))
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestRespondAttachment(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/statements/1", nil)
+	w := httptest.NewRecorder()
+
+	err := httprouter.RespondAttachment(w, req, "resumen de cuenta.csv", strings.NewReader("id,amount\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `attachment; filename="resumen de cuenta.csv"`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+	assert.Equal(t, "id,amount\n", w.Body.String())
+}
+
+func TestRespondRedirect(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/cards", nil)
+	w := httptest.NewRecorder()
+
+	assert.NoError(t, httprouter.RespondRedirect(w, req, http.StatusPermanentRedirect, "/v2/cards"))
+	assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+	assert.Equal(t, "/v2/cards", w.Header().Get("Location"))
+
+	for _, code := range []int{http.StatusOK, http.StatusNotModified, http.StatusUseProxy, 306, http.StatusBadRequest} {
+		err := httprouter.RespondRedirect(httptest.NewRecorder(), req, code, "/v2/cards")
+		assert.EqualError(t, err, fmt.Sprintf("500 internal_server_error: invalid redirect status code: %d", code))
+	}
+}
@@ -0,0 +1,137 @@
+package httprouter
+
+import (
+	"net/http"
+	"time"
+)
+
+// RouteOptions represents the metadata of a route, used to document it in the
+// OpenAPI document of the router, and the limits it is served with.
+type RouteOptions struct {
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Request     any
+	Responses   map[int]any
+	Security    []string
+	MaxBodySize int64
+	Timeout     time.Duration
+}
+
+// WithOperationID allows you to configure the unique identifier of the
+// operation of the route.
+func WithOperationID(operationID string) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		opts.OperationID = operationID
+	}
+}
+
+// WithSummary allows you to configure a short summary of what the route does.
+func WithSummary(summary string) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		opts.Summary = summary
+	}
+}
+
+// WithDescription allows you to configure a verbose explanation of the
+// behavior of the route. CommonMark syntax may be used.
+func WithDescription(description string) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		opts.Description = description
+	}
+}
+
+// WithTags allows you to configure the tags used to group the route in the
+// documentation.
+func WithTags(tags ...string) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		opts.Tags = append(opts.Tags, tags...)
+	}
+}
+
+// WithRequest allows you to configure the request bound by the route, like
+// Bind does. Fields tagged with "path", "query" and "header" are documented
+// as parameters, fields tagged with "form" as a form body, and the rest as a
+// JSON body, along with the constraints of their "validate" tags.
+//
+//	r.Post("/cards/{id}/block", blockCard, httprouter.WithRequest(BlockCardRequest{}))
+func WithRequest(request any) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		opts.Request = request
+	}
+}
+
+// WithResponse allows you to configure a response of the route with the given
+// status code and body. A nil body documents a response without content.
+//
+// Default behavior is to document a single http.StatusOK response without content.
+func WithResponse(statusCode int, body any) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		if opts.Responses == nil {
+			opts.Responses = make(map[int]any)
+		}
+		opts.Responses[statusCode] = body
+	}
+}
+
+// WithSecurity allows you to configure the security schemes accepted by the
+// route, any of them being enough to access it. The schemes are declared
+// with OpenAPIConfig.SecuritySchemes.
+func WithSecurity(schemes ...string) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		opts.Security = append(opts.Security, schemes...)
+	}
+}
+
+// WithMaxBodySize allows you to configure the maximum size in bytes of the
+// body of the requests of the route, as MaxBodySize does. Larger bodies are
+// answered with http.StatusRequestEntityTooLarge.
+//
+// Default behavior is to not limit the body.
+func WithMaxBodySize(limit int64) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		opts.MaxBodySize = limit
+	}
+}
+
+// WithTimeout allows you to configure the deadline of the handler of the
+// route, as Timeout does. Requests that time out are answered with
+// http.StatusServiceUnavailable, or http.StatusGatewayTimeout when the handler
+// returns an error caused by the deadline.
+//
+// Default behavior is to not set any deadline.
+func WithTimeout(timeout time.Duration) func(opts *RouteOptions) {
+	return func(opts *RouteOptions) {
+		opts.Timeout = timeout
+	}
+}
+
+// routeHandler is the http.Handler registered for the routes of a Router,
+// which keeps their metadata available for Routes.
+type routeHandler struct {
+	handler http.HandlerFunc
+	options RouteOptions
+	version *apiVersion
+}
+
+func (h *routeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.handler(w, r)
+}
+
+// route adapts handler into the routeHandler of a route with the given options.
+func (r *Router) route(handler Handler, optFns []func(opts *RouteOptions)) *routeHandler {
+	var opts RouteOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	if opts.Timeout > 0 {
+		handler = timeoutHandler(handler, opts.Timeout)
+	}
+	if opts.MaxBodySize > 0 {
+		handler = limitBodyHandler(handler, opts.MaxBodySize)
+	}
+
+	return &routeHandler{handler: r.handlerFunc(handler), options: opts, version: r.version}
+}
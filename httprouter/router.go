@@ -1,23 +1,45 @@
 package httprouter
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"sync"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/pomelo-la/go-toolkit/logger"
 )
 
 // Handler is a type that handles a http request within our framework.
 type Handler func(w http.ResponseWriter, r *http.Request) error
 
+// ServeHTTP conforms to the http.Handler interface, responding the error
+// returned by h, if any, with the ErrorHandlerFunc and the format of the
+// Router serving the request, like the middlewares that use it do. Outside
+// a Router errors are responded with DefaultHandlerError.
 func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if err := h(w, r); err != nil {
-		handleErr := DefaultHandlerError(err)
-		_ = RespondJSON(w, handleErr.StatusCode, handleErr.Error)
+	err := h(w, r)
+	if err == nil {
+		return
+	}
+
+	if respond, ok := r.Context().Value(errorResponderKey{}).(errorResponder); ok {
+		respond(w, r, err)
+		return
 	}
+
+	handleErr := DefaultHandlerError(err)
+	_ = RespondJSON(w, handleErr.StatusCode, handleErr.Error)
 }
 
+// errorResponder responds err in the format of a Router.
+type errorResponder func(w http.ResponseWriter, r *http.Request, err error)
+
+type errorResponderKey struct{}
+
 // Config allows configuring a Router instance.
 type Config struct {
 	ErrorHandlerFunc            ErrorHandlerFunc
@@ -25,12 +47,19 @@ type Config struct {
 	HealthCheckLivenessHandler  http.Handler
 	HealthCheckReadinessHandler http.Handler
 	EnableProfiler              bool
+	ProblemDetails              bool
+	Logger                      *logger.Logger
+	OpenAPI                     *OpenAPIConfig
+	Versioning                  *VersioningConfig
 
 	Middlewares []func(http.Handler) http.Handler
 }
 
 // WithErrorHandlerFunc allows you to configure the ErrorHandlerFunc for use to
-// router.
+// router. It is applied to the errors returned by every route, including the
+// ones of the routers created with With, Group and Route.
+//
+// Default behavior is to use DefaultHandlerError.
 func WithErrorHandlerFunc(errorHandlerFunc ErrorHandlerFunc) func(options *Config) {
 	return func(opt *Config) {
 		opt.ErrorHandlerFunc = errorHandlerFunc
@@ -69,6 +98,27 @@ func WithEnableProfiler(enableProfiler bool) func(options *Config) {
 	}
 }
 
+// WithProblemDetails allows you to configure the router to respond errors as
+// "application/problem+json" Problem Details objects, as defined by RFC 9457.
+// Routers created with With, Group and Route inherit this configuration.
+//
+// Default behavior is to respond errors with the legacy {message, error, status} format.
+func WithProblemDetails(enabled bool) func(options *Config) {
+	return func(opt *Config) {
+		opt.ProblemDetails = enabled
+	}
+}
+
+// WithLogger allows you to configure the Logger used to log the errors
+// that the ErrorHandlerFunc marks to be notified.
+//
+// Default behavior is to only record those errors on the active span.
+func WithLogger(log *logger.Logger) func(options *Config) {
+	return func(opt *Config) {
+		opt.Logger = log
+	}
+}
+
 // WithGlobalMiddlewares allows you to configure the
 // Middlewares for use to router.
 func WithGlobalMiddlewares(middlewares ...func(http.Handler) http.Handler) func(options *Config) {
@@ -82,6 +132,12 @@ func WithGlobalMiddlewares(middlewares ...func(http.Handler) http.Handler) func(
 type Router struct {
 	mux            chi.Router
 	errHandlerFunc ErrorHandlerFunc
+	problemDetails bool
+	log            *logger.Logger
+	version        *apiVersion
+	versions       *versionSet
+	cors           bool
+	options        *patternSet
 }
 
 // New instantiates a `Router` with the given configuration.
@@ -93,6 +149,19 @@ func New(optFns ...func(options *Config)) *Router {
 		fn(&opts)
 	}
 
+	router := &Router{
+		mux:            mux,
+		errHandlerFunc: opts.ErrorHandlerFunc,
+		problemDetails: opts.ProblemDetails,
+		log:            opts.Logger,
+		versions:       newVersionSet(),
+		options:        newPatternSet(),
+	}
+
+	if opts.Versioning != nil {
+		mux.Use(router.negotiateVersion(*opts.Versioning))
+	}
+
 	if opts.Middlewares != nil {
 		mux.Use(opts.Middlewares...)
 	}
@@ -113,9 +182,24 @@ func New(optFns ...func(options *Config)) *Router {
 		mux.Mount("/debug", middleware.Profiler())
 	}
 
+	if opts.OpenAPI != nil {
+		router.serveOpenAPI(*opts.OpenAPI)
+	}
+
+	return router
+}
+
+// child returns a Router that dispatches to mux with the configuration of r.
+func (r *Router) child(mux chi.Router) *Router {
 	return &Router{
 		mux:            mux,
-		errHandlerFunc: opts.ErrorHandlerFunc,
+		errHandlerFunc: r.errHandlerFunc,
+		problemDetails: r.problemDetails,
+		log:            r.log,
+		version:        r.version,
+		versions:       r.versions,
+		cors:           r.cors,
+		options:        r.options,
 	}
 }
 
@@ -131,9 +215,7 @@ func (r *Router) Use(middleware ...func(http.Handler) http.Handler) {
 
 // With adds inline middlewares for an endpoint handler.
 func (r *Router) With(middlewares ...func(http.Handler) http.Handler) *Router {
-	return &Router{
-		mux: r.mux.With(middlewares...),
-	}
+	return r.child(r.mux.With(middlewares...))
 }
 
 // Group creates a new inline-Mux with a copy of middleware stack. It's useful
@@ -155,9 +237,12 @@ func (r *Router) Route(pattern string, fn func(r Router)) *Router {
 		panic(fmt.Sprintf("httrouter: attempting to Route() a nil subrouter on '%s'", pattern))
 	}
 
-	subRouter := New()
+	subRouter := r.child(chi.NewRouter())
+	subRouter.versions = nil
+	subRouter.options = newPatternSet()
 	fn(*subRouter)
-	r.mux.Mount(pattern, subRouter)
+	// The mux is mounted so its routes are walked by Routes.
+	r.mux.Mount(pattern, subRouter.mux)
 
 	return subRouter
 }
@@ -166,66 +251,147 @@ func (r *Router) Route(pattern string, fn func(r Router)) *Router {
 // path. It's very useful to split up a large API as many independent routers and
 // compose them as a single service using Mount.
 func (r *Router) Mount(pattern string, handler Handler) {
-	r.mux.Mount(pattern, handler)
+	r.mux.Mount(pattern, r.handlerFunc(handler))
 }
 
 // Get adds the route `pattern` that matches a GET http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Get(pattern string, handler Handler) {
-	r.mux.Get(pattern, handler.ServeHTTP)
+func (r *Router) Get(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodGet, pattern, handler, optFns)
 }
 
 // Delete adds the route `pattern` that matches a DELETE http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Delete(pattern string, handler Handler) {
-	r.mux.Delete(pattern, handler.ServeHTTP)
+func (r *Router) Delete(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodDelete, pattern, handler, optFns)
 }
 
 // Head adds the route `pattern` that matches a HEAD http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Head(pattern string, handler Handler) {
-	r.mux.Head(pattern, handler.ServeHTTP)
+func (r *Router) Head(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodHead, pattern, handler, optFns)
 }
 
 // Options adds the route `pattern` that matches a OPTIONS http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Options(pattern string, handler Handler) {
-	r.mux.Options(pattern, handler.ServeHTTP)
+func (r *Router) Options(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodOptions, pattern, handler, optFns)
 }
 
 // Patch adds the route `pattern` that matches a PATCH http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Patch(pattern string, handler Handler) {
-	r.mux.Patch(pattern, handler.ServeHTTP)
+func (r *Router) Patch(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodPatch, pattern, handler, optFns)
 }
 
 // Post adds the route `pattern` that matches a Post http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Post(pattern string, handler Handler) {
-	r.mux.Post(pattern, handler.ServeHTTP)
+func (r *Router) Post(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodPost, pattern, handler, optFns)
 }
 
 // Put adds the route `pattern` that matches a PUT http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Put(pattern string, handler Handler) {
-	r.mux.Put(pattern, handler.ServeHTTP)
+func (r *Router) Put(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodPut, pattern, handler, optFns)
 }
 
 // Trace adds the route `pattern` that matches a TRACE http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Trace(pattern string, handler Handler) {
-	r.mux.Trace(pattern, handler.ServeHTTP)
+func (r *Router) Trace(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodTrace, pattern, handler, optFns)
 }
 
 // Connect adds the route `pattern` that matches a CONNECT http method to
 // execute the `handlerFn` http.HandlerFunc.
-func (r *Router) Connect(pattern string, handler Handler) {
-	r.mux.Connect(pattern, handler.ServeHTTP)
+func (r *Router) Connect(pattern string, handler Handler, optFns ...func(opts *RouteOptions)) {
+	r.handle(http.MethodConnect, pattern, handler, optFns)
+}
+
+// handle registers the route of handler for the given method and pattern.
+// On the routers created with WithCORS, it also registers an OPTIONS route
+// for the pattern, unless it has one, so the CORS middleware can answer its
+// preflight requests.
+func (r *Router) handle(method, pattern string, handler Handler, optFns []func(opts *RouteOptions)) {
+	r.mux.Method(method, pattern, r.route(handler, optFns))
+
+	switch {
+	case method == http.MethodOptions:
+		r.options.add(pattern)
+	case r.cors && r.options.add(pattern):
+		r.mux.MethodFunc(http.MethodOptions, pattern, r.handlerFunc(corsOptionsHandler))
+	}
+}
+
+// patternSet holds the patterns of the routes of a mux with an OPTIONS
+// handler.
+type patternSet struct {
+	mu       sync.Mutex
+	patterns map[string]struct{}
+}
+
+func newPatternSet() *patternSet {
+	return &patternSet{patterns: make(map[string]struct{})}
 }
 
-// ServeHTTP conforms to the http.Handler interface.
+// add adds pattern to the set, reporting whether it was not in it.
+func (s *patternSet) add(pattern string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.patterns[pattern]; ok {
+		return false
+	}
+	s.patterns[pattern] = struct{}{}
+
+	return true
+}
+
+// handlerFunc adapts handler into a http.HandlerFunc that handles the errors
+// returned by handler with the ErrorHandlerFunc and the format of the router.
+func (r *Router) handlerFunc(handler Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err := handler(w, req); err != nil {
+			r.respondError(w, req, err)
+		}
+	}
+}
+
+// respondError responds err with the ErrorHandlerFunc and the format of the
+// router.
+func (r *Router) respondError(w http.ResponseWriter, req *http.Request, err error) {
+	// There is no one to respond to once the client is gone,
+	// like when it disconnects from a stream.
+	if errors.Is(err, context.Canceled) && req.Context().Err() != nil {
+		return
+	}
+
+	handleErr := r.handleError(err)
+	if handleErr.Notify {
+		notifyError(req, r.log, err, handleErr.StatusCode)
+	}
+
+	if r.problemDetails {
+		_ = writeProblem(w, problemOf(req, handleErr))
+		return
+	}
+	_ = RespondJSON(w, handleErr.StatusCode, handleErr.Error)
+}
+
+func (r *Router) handleError(err error) HandlerError {
+	if r.errHandlerFunc == nil {
+		return DefaultHandlerError(err)
+	}
+
+	return r.errHandlerFunc(err, DefaultHandlerError)
+}
+
+// ServeHTTP conforms to the http.Handler interface. The errors returned by
+// the Handler middlewares are responded in the format of the router, see
+// Handler.ServeHTTP.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	r.mux.ServeHTTP(w, req)
+	ctx := context.WithValue(req.Context(), errorResponderKey{}, errorResponder(r.respondError))
+	r.mux.ServeHTTP(w, req.WithContext(ctx))
 }
 
 // Route describes the details of a routing handler.
@@ -234,17 +400,33 @@ type Route struct {
 	Route       string
 	Handler     http.Handler
 	Middlewares []func(http.Handler) http.Handler
+	// Options are the options the route was registered with, empty for
+	// the routes not registered through a Router.
+	Options RouteOptions
+	// Version is the version the route was registered in with Version, if any.
+	Version string
 }
 
 // Routes returns the routing tree in an easily traversable structure.
 func (r *Router) Routes() ([]Route, error) {
 	var routes []Route
 	walkFunc := func(method string, route string, handler http.Handler, mw ...func(http.Handler) http.Handler) error {
+		var opts RouteOptions
+		var version string
+		if h, ok := handler.(*routeHandler); ok {
+			opts = h.options
+			if h.version != nil {
+				version = h.version.name
+			}
+		}
+
 		routes = append(routes, Route{
 			Method:      method,
 			Route:       route,
 			Handler:     handler,
 			Middlewares: mw,
+			Options:     opts,
+			Version:     version,
 		})
 		return nil
 	}
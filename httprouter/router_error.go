@@ -2,9 +2,21 @@ package httprouter
 
 import (
 	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pomelo-la/go-toolkit/logger"
 )
 
 // HandlerError represents an error returned by an ErrorHandler.
+//
+// Error is the value sent to the client with StatusCode, while Notify marks
+// the error to be recorded on the active span and logged.
 type HandlerError struct {
 	StatusCode int
 	Error      any
@@ -14,12 +26,69 @@ type HandlerError struct {
 // ErrorHandlerFunc is used to define centralized error handler for your application.
 type ErrorHandlerFunc func(err error, defaultHandlerError func(error) HandlerError) HandlerError
 
+// errorMapping is the HTTP representation of a domain error.
+type errorMapping struct {
+	target     error
+	statusCode int
+	code       string
+}
+
+var (
+	_errorMappingsMu sync.RWMutex
+	_errorMappings   []errorMapping
+)
+
+// RegisterError declares the status code and business error code used by
+// DefaultHandlerError to respond the errors that match target, as reported
+// by errors.Is. Mappings are checked in the order they were registered.
+//
+//	httprouter.RegisterError(cards.ErrCardNotFound, http.StatusNotFound, "card_not_found")
+//
+// An empty code is replaced with the one derived from the status code.
+func RegisterError(target error, statusCode int, code string) {
+	if code == "" {
+		code = statusCodeText(statusCode)
+	}
+
+	_errorMappingsMu.Lock()
+	defer _errorMappingsMu.Unlock()
+
+	_errorMappings = append(_errorMappings, errorMapping{target: target, statusCode: statusCode, code: code})
+}
+
+// mapError converts err into an Error with the first registered mapping that
+// matches it, after the errors of reading a body past its limit.
+func mapError(err error) (*Error, bool) {
+	if webErr, ok := bodyTooLarge(err); ok {
+		return webErr, true
+	}
+
+	_errorMappingsMu.RLock()
+	defer _errorMappingsMu.RUnlock()
+
+	for _, m := range _errorMappings {
+		if errors.Is(err, m.target) {
+			return WrapError(m.statusCode, err).WithCode(m.code), true
+		}
+	}
+
+	return nil, false
+}
+
 // DefaultHandlerError is a default implementation of an error handler.
 // It converts the given error into a HandlerError object.
+//
+// Errors that are not an Error are converted with the mappings declared with
+// RegisterError, or into a 500 status code error otherwise. Errors caused by
+// reading a body past the limit set by MaxBodySize are converted into a 413
+// status code error.
 func DefaultHandlerError(err error) HandlerError {
 	var webErr *Error
 	if !errors.As(err, &webErr) {
-		webErr = NewErrorf(500, err.Error()).(*Error)
+		var mapped bool
+		if webErr, mapped = mapError(err); !mapped {
+			webErr = WrapError(http.StatusInternalServerError, err)
+		}
 	}
 
 	return HandlerError{
@@ -28,3 +97,26 @@ func DefaultHandlerError(err error) HandlerError {
 		Notify:     webErr.StatusCode >= 500 && webErr.StatusCode <= 599,
 	}
 }
+
+// notifyError records err on the active span of the request and logs it
+// with log, if not nil.
+func notifyError(r *http.Request, log *logger.Logger, err error, statusCode int) {
+	span := trace.SpanFromContext(r.Context())
+	span.RecordError(err, trace.WithAttributes(
+		attribute.String("http.method", r.Method),
+		attribute.String("http.target", r.URL.Path),
+		attribute.Int("http.status_code", statusCode),
+	))
+	span.SetStatus(codes.Error, err.Error())
+
+	if log == nil {
+		return
+	}
+
+	log.Error(r.Context(), "request failed",
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", statusCode),
+		slog.String("error_msg", err.Error()),
+	)
+}
@@ -1,17 +1,25 @@
 package httprouter_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 
 	"github.com/pomelo-la/go-toolkit/httprouter"
+	"github.com/pomelo-la/go-toolkit/logger"
 )
 
 func TestNewConfigHandlers(t *testing.T) {
@@ -109,7 +117,7 @@ func TestNewConfigHandlers(t *testing.T) {
 func TestRouterMethod(t *testing.T) {
 	tests := []struct {
 		name     string
-		shortcut func(r *httprouter.Router, path string, handler httprouter.Handler)
+		shortcut func(r *httprouter.Router, path string, handler httprouter.Handler, optFns ...func(opts *httprouter.RouteOptions))
 		method   string
 	}{
 		{
@@ -195,6 +203,24 @@ func TestRouterRoutes(t *testing.T) {
 	assert.NotNil(t, routes[0].Handler)
 }
 
+func TestRouterRoutesOptions(t *testing.T) {
+	r := httprouter.New()
+	h := func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}
+	r.Route("/cards", func(r httprouter.Router) {
+		r.With(middleware.NoCache).Get("/{id}", h, httprouter.WithSummary("Get a card"), httprouter.WithTags("cards"))
+	})
+
+	routes, err := r.Routes()
+
+	require.NoError(t, err)
+	require.Len(t, routes, 1)
+	assert.Equal(t, "/cards/{id}", routes[0].Route)
+	assert.Len(t, routes[0].Middlewares, 1)
+	assert.Equal(t, httprouter.RouteOptions{Summary: "Get a card", Tags: []string{"cards"}}, routes[0].Options)
+}
+
 func TestRouterHandlerReturnNoError(t *testing.T) {
 	var mwWasCalled bool
 	mw := func(f http.Handler) http.Handler {
@@ -339,22 +365,27 @@ func TestRouterErrorHandler(t *testing.T) {
 		}
 
 		t.Run(tt.name, func(t *testing.T) {
-			config := httprouter.Config{}
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
 
-			if tt.errorHandlerFunc != nil {
-				config.ErrorHandlerFunc = tt.errorHandlerFunc
-			}
+			var logs bytes.Buffer
+			log := logger.NewWithHandler(slog.NewJSONHandler(&logs, nil))
 
-			router := httprouter.New()
+			router := httprouter.New(
+				httprouter.WithErrorHandlerFunc(tt.errorHandlerFunc),
+				httprouter.WithLogger(log),
+			)
 			router.Use(mw)
 			router.Get("/{id}", tt.handler.spy())
 
 			rr := httptest.NewRecorder()
 			req := httptest.NewRequest("GET", "/1", nil)
 
-			req = req.WithContext(req.Context())
+			ctx, span := provider.Tracer("test").Start(req.Context(), "request")
+			req = req.WithContext(ctx)
 
 			router.ServeHTTP(rr, req)
+			span.End()
 
 			assert.Equal(t, tt.expectedCode, rr.Code)
 			assert.True(t, mwWasCalled)
@@ -364,8 +395,58 @@ func TestRouterErrorHandler(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			if tt.shouldNotify {
+				assert.Equal(t, codes.Error, spans[0].Status().Code)
+				require.Len(t, spans[0].Events(), 1)
+				assert.Equal(t, "exception", spans[0].Events()[0].Name)
+				assert.Contains(t, logs.String(), `"msg":"request failed"`)
+				assert.Contains(t, logs.String(), fmt.Sprintf(`"error_msg":%q`, tt.handler.err.Error()))
+			} else {
+				assert.Equal(t, codes.Unset, spans[0].Status().Code)
+				assert.Empty(t, logs.String())
+			}
+
 			assert.Equal(t, tt.expectedErrMessage, webErr.Message)
 		})
 
 	}
 }
+
+func TestRouterErrorHandlerSubRouters(t *testing.T) {
+	errNotFound := errors.New("card not found")
+	errorHandlerFunc := func(err error, defaultHandlerError func(error) httprouter.HandlerError) httprouter.HandlerError {
+		if errors.Is(err, errNotFound) {
+			return httprouter.HandlerError{
+				StatusCode: http.StatusNotFound,
+				Error:      httprouter.NewError(http.StatusNotFound, err.Error()),
+			}
+		}
+		return defaultHandlerError(err)
+	}
+
+	handler := func(w http.ResponseWriter, r *http.Request) error {
+		return errNotFound
+	}
+
+	router := httprouter.New(httprouter.WithErrorHandlerFunc(errorHandlerFunc))
+	router.Get("/cards", handler)
+	router.With().Get("/with", handler)
+	router.Group(func(r httprouter.Router) {
+		r.Get("/group", handler)
+	})
+	router.Route("/route", func(r httprouter.Router) {
+		r.Get("/cards", handler)
+	})
+
+	for _, path := range []string{"/cards", "/with", "/group", "/route/cards"} {
+		t.Run(path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+			assert.Equal(t, http.StatusNotFound, rr.Code)
+			assert.JSONEq(t, `{"message":"card not found","error":"not_found","status":404}`, rr.Body.String())
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 )
@@ -78,6 +79,14 @@ func RunTLS(ln net.Listener, timeouts Timeouts, h http.Handler, tlsConfig *tls.C
 }
 
 func run(server *http.Server, shutdownTimeout time.Duration, ln net.Listener, serveTLS bool) error {
+	// Hijacked connections, like WebSockets, are not tracked by the server, so
+	// they are notified of the shutdown and waited for through the tracker.
+	tracker := newConnTracker()
+	server.BaseContext = func(net.Listener) context.Context {
+		return context.WithValue(context.Background(), connTrackerKey{}, tracker)
+	}
+	server.RegisterOnShutdown(tracker.shutdown)
+
 	// Make a channel to listen for errors coming from the listener. Use a
 	// buffered channel so the goroutine can exit if we don't collect this error.
 	serverErrors := make(chan error, 1)
@@ -107,6 +116,9 @@ func run(server *http.Server, shutdownTimeout time.Duration, ln net.Listener, se
 
 		// Asking listener to shut down and shed load.
 		err := server.Shutdown(ctx)
+		if err == nil {
+			err = tracker.wait(ctx)
+		}
 		if err == nil {
 			return nil
 		}
@@ -120,3 +132,82 @@ func run(server *http.Server, shutdownTimeout time.Duration, ln net.Listener, se
 
 	return nil
 }
+
+type connTrackerKey struct{}
+
+// connTracker notifies the connections hijacked from the server that it is
+// shutting down, and waits for them to be closed.
+type connTracker struct {
+	mu           sync.Mutex
+	active       int
+	idle         chan struct{}
+	shuttingDown chan struct{}
+	once         sync.Once
+}
+
+func newConnTracker() *connTracker {
+	return &connTracker{
+		idle:         make(chan struct{}),
+		shuttingDown: make(chan struct{}),
+	}
+}
+
+func (t *connTracker) shutdown() {
+	t.once.Do(func() {
+		close(t.shuttingDown)
+	})
+}
+
+func (t *connTracker) track() func() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.active++
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		t.active--
+		if t.active == 0 {
+			close(t.idle)
+			t.idle = make(chan struct{})
+		}
+	}
+}
+
+func (t *connTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.active == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// trackConnection registers a connection to be hijacked from the server
+// started by Run, if any. The returned function must be called once it is closed.
+func trackConnection(ctx context.Context) func() {
+	tracker, ok := ctx.Value(connTrackerKey{}).(*connTracker)
+	if !ok {
+		return func() {}
+	}
+	return tracker.track()
+}
+
+// shuttingDown returns a channel that is closed when the server started by
+// Run begins to shut down, or nil when the server was not started by Run.
+func shuttingDown(ctx context.Context) <-chan struct{} {
+	tracker, ok := ctx.Value(connTrackerKey{}).(*connTracker)
+	if !ok {
+		return nil
+	}
+	return tracker.shuttingDown
+}
@@ -0,0 +1,328 @@
+package httprouter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Supported streaming MIME Content-Types.
+const (
+	_mimeTextEventStream   = "text/event-stream"
+	_mimeApplicationNDJSON = "application/x-ndjson"
+)
+
+const (
+	_headerLastEventID   = "Last-Event-ID"
+	_defaultSSEKeepAlive = 15 * time.Second
+	_sseKeepAliveComment = ": keep-alive\n\n"
+)
+
+// Event is a Server-Sent Event.
+type Event struct {
+	// ID sets the last event ID of the client, sent back in the
+	// "Last-Event-ID" header when it reconnects.
+	ID string
+	// Name is the type of the event, "message" being used by the client when empty.
+	Name string
+	// Data is the payload of the event. Strings and byte slices are sent as
+	// they are, any other value is encoded as JSON.
+	Data any
+	// Retry is the reconnection time of the client.
+	Retry time.Duration
+}
+
+// SSEOptions represents the options for configuring a SSE writer.
+type SSEOptions struct {
+	KeepAlive time.Duration
+	Retry     time.Duration
+}
+
+// WithKeepAlive allows you to configure the interval of the comments sent to
+// keep the connection alive through proxies. A zero interval disables them.
+//
+// Default behavior is to send a comment every 15 seconds.
+func WithKeepAlive(interval time.Duration) func(opts *SSEOptions) {
+	return func(opts *SSEOptions) {
+		opts.KeepAlive = interval
+	}
+}
+
+// WithRetry allows you to configure the reconnection time sent to the client
+// when the stream starts.
+//
+// Default behavior is to let the client choose the reconnection time.
+func WithRetry(retry time.Duration) func(opts *SSEOptions) {
+	return func(opts *SSEOptions) {
+		opts.Retry = retry
+	}
+}
+
+// SSE writes Server-Sent Events to the client. It is safe for concurrent use.
+type SSE struct {
+	mu          sync.Mutex
+	w           io.Writer
+	rc          *http.ResponseController
+	ctx         context.Context
+	lastEventID string
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewSSE starts a Server-Sent Events stream. The stream is flushed after every
+// event, also through the middlewares that wrap the http.ResponseWriter, and
+// Close must be called once the handler is done with it.
+//
+//	r.Get("/operations/{id}/progress", func(w http.ResponseWriter, r *http.Request) error {
+//		sse, err := httprouter.NewSSE(w, r)
+//		if err != nil {
+//			return err
+//		}
+//		defer sse.Close()
+//
+//		for p := range progress(r.Context(), sse.LastEventID()) {
+//			if err := sse.Send(httprouter.Event{ID: p.ID, Data: p}); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	})
+func NewSSE(w http.ResponseWriter, r *http.Request, optFns ...func(opts *SSEOptions)) (*SSE, error) {
+	opts := SSEOptions{
+		KeepAlive: _defaultSSEKeepAlive,
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	rc, err := startStream(w, _mimeTextEventStream)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &SSE{
+		w:           w,
+		rc:          rc,
+		ctx:         r.Context(),
+		lastEventID: r.Header.Get(_headerLastEventID),
+		stop:        make(chan struct{}),
+	}
+
+	if opts.Retry > 0 {
+		if err := s.write("retry: " + strconv.FormatInt(opts.Retry.Milliseconds(), 10) + "\n\n"); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.KeepAlive > 0 {
+		go s.keepAlive(opts.KeepAlive)
+	}
+
+	return s, nil
+}
+
+// LastEventID returns the ID of the last event received by the client before
+// reconnecting, which allows resuming the stream.
+func (s *SSE) LastEventID() string {
+	return s.lastEventID
+}
+
+// Done returns a channel that is closed when the client disconnects.
+func (s *SSE) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Send writes the event to the client. It returns the error of the request
+// context once the client disconnects.
+func (s *SSE) Send(e Event) error {
+	var b strings.Builder
+	if e.ID != "" {
+		b.WriteString("id: " + singleLine(e.ID) + "\n")
+	}
+	if e.Name != "" {
+		b.WriteString("event: " + singleLine(e.Name) + "\n")
+	}
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	data, err := eventData(e.Data)
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+
+	return s.write(b.String())
+}
+
+// Close stops the keep-alive comments. Once it returns nothing else is
+// written to the client, so the handler may return.
+func (s *SSE) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+
+	// Waits for an in-flight write, later ones see the stream stopped.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+}
+
+func (s *SSE) keepAlive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.write(_sseKeepAliveComment); err != nil {
+				return
+			}
+		case <-s.stop:
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *SSE) write(chunk string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	select {
+	case <-s.stop:
+		return fmt.Errorf("writing event: %w", io.ErrClosedPipe)
+	default:
+	}
+
+	if _, err := io.WriteString(s.w, chunk); err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+func eventData(data any) (string, error) {
+	switch v := data.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+}
+
+func singleLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// NDJSON writes a stream of newline delimited JSON values to the client.
+type NDJSON struct {
+	enc *json.Encoder
+	rc  *http.ResponseController
+	ctx context.Context
+}
+
+// NewNDJSON starts a newline delimited JSON stream, flushed after every value.
+func NewNDJSON(w http.ResponseWriter, r *http.Request) (*NDJSON, error) {
+	rc, err := startStream(w, _mimeApplicationNDJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NDJSON{enc: json.NewEncoder(w), rc: rc, ctx: r.Context()}, nil
+}
+
+// Encode writes value to the client as a JSON line. It returns the error of
+// the request context once the client disconnects.
+func (s *NDJSON) Encode(value any) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := s.enc.Encode(value); err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+// StreamNDJSON writes the values received from values to the client as a
+// newline delimited JSON stream, until values is closed or the client
+// disconnects, in which case it returns the error of the request context.
+func StreamNDJSON[T any](w http.ResponseWriter, r *http.Request, values <-chan T) error {
+	stream, err := NewNDJSON(w, r)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return r.Context().Err()
+		case v, ok := <-values:
+			if !ok {
+				return nil
+			}
+			if err := stream.Encode(v); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// startStream sends the headers of a streamed response with the given
+// Content-Type, making sure the response writer supports flushing.
+func startStream(w http.ResponseWriter, contentType string) (*http.ResponseController, error) {
+	if !canFlush(w) {
+		return nil, NewError(http.StatusInternalServerError, "streaming unsupported by the response writer")
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	if err := rc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return rc, nil
+}
+
+// canFlush reports whether w, or any of the response writers it wraps, as
+// reported by their Unwrap method, implements http.Flusher.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		if _, ok := w.(http.Flusher); ok {
+			return true
+		}
+
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+}
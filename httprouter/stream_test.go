@@ -0,0 +1,186 @@
+package httprouter_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+// streamingRouter returns a router wrapped by the same middlewares webapp uses.
+func streamingRouter() *httprouter.Router {
+	wrap := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(middleware.NewWrapResponseWriter(w, r.ProtoMajor), r)
+		})
+	}
+
+	return httprouter.New(httprouter.WithGlobalMiddlewares(
+		wrap,
+		middleware.Compress(5, "text/event-stream", "application/x-ndjson"),
+	))
+}
+
+func readUntilBlankLine(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		if line == "\n" {
+			return strings.Join(lines, "")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestSSE(t *testing.T) {
+	read := make(chan struct{})
+	done := make(chan error, 1)
+
+	r := streamingRouter()
+	r.Get("/progress", func(w http.ResponseWriter, r *http.Request) error {
+		sse, err := httprouter.NewSSE(w, r, httprouter.WithRetry(3*time.Second), httprouter.WithKeepAlive(10*time.Millisecond))
+		if err != nil {
+			return err
+		}
+		defer sse.Close()
+
+		err = sse.Send(httprouter.Event{ID: "2", Name: "progress", Data: map[string]int{"percent": 50, "resumed_from": len(sse.LastEventID())}})
+		if err != nil {
+			return err
+		}
+
+		// The first event must reach the client before the handler moves on.
+		<-read
+
+		err = sse.Send(httprouter.Event{ID: "3", Data: "line 1\nline 2"})
+		if err != nil {
+			return err
+		}
+
+		<-sse.Done()
+		done <- sse.Send(httprouter.Event{ID: "4", Data: "too late"})
+		return nil
+	})
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/progress", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+
+	res, err := srv.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	assert.True(t, res.Uncompressed, "response should be gzipped")
+
+	body := bufio.NewReader(res.Body)
+	assert.Equal(t, "retry: 3000\n", readUntilBlankLine(t, body))
+	assert.Equal(t, "id: 2\nevent: progress\ndata: {\"percent\":50,\"resumed_from\":1}\n", readUntilBlankLine(t, body))
+	close(read)
+
+	event := readUntilBlankLine(t, body)
+	for event == ": keep-alive\n" {
+		event = readUntilBlankLine(t, body)
+	}
+	assert.Equal(t, "id: 3\ndata: line 1\ndata: line 2\n", event)
+	assert.Equal(t, ": keep-alive\n", readUntilBlankLine(t, body))
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(time.Second):
+		t.Fatal("handler did not stop on client disconnect")
+	}
+}
+
+func TestSSE_Close(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/progress", nil)
+
+	sse, err := httprouter.NewSSE(recorder, request, httprouter.WithKeepAlive(time.Millisecond))
+	require.NoError(t, err)
+
+	time.Sleep(10 * time.Millisecond)
+	sse.Close()
+	body := recorder.Body.String()
+
+	time.Sleep(10 * time.Millisecond)
+	assert.Contains(t, body, ": keep-alive\n")
+	assert.Equal(t, body, recorder.Body.String())
+	assert.ErrorIs(t, sse.Send(httprouter.Event{Data: "too late"}), io.ErrClosedPipe)
+}
+
+func TestStreamNDJSON(t *testing.T) {
+	type movement struct {
+		ID     string `json:"id"`
+		Amount int    `json:"amount"`
+	}
+
+	r := streamingRouter()
+	r.Get("/movements", func(w http.ResponseWriter, r *http.Request) error {
+		movements := make(chan movement)
+		go func() {
+			defer close(movements)
+			for _, m := range []movement{{ID: "mov-1", Amount: 100}, {ID: "mov-2", Amount: -30}} {
+				movements <- m
+			}
+		}()
+
+		return httprouter.StreamNDJSON(w, r, movements)
+	})
+
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	res, err := srv.Client().Get(srv.URL + "/movements")
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/x-ndjson", res.Header.Get("Content-Type"))
+
+	body := bufio.NewScanner(res.Body)
+	var lines []string
+	for body.Scan() {
+		lines = append(lines, body.Text())
+	}
+	assert.Equal(t, []string{`{"id":"mov-1","amount":100}`, `{"id":"mov-2","amount":-30}`}, lines)
+}
+
+type nonFlushingWriter struct {
+	http.ResponseWriter
+}
+
+func TestNewSSE_StreamingUnsupported(t *testing.T) {
+	r := httprouter.New()
+	r.Get("/progress", func(w http.ResponseWriter, r *http.Request) error {
+		_, err := httprouter.NewSSE(nonFlushingWriter{w}, r)
+		return err
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/progress", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "streaming unsupported")
+}
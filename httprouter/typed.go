@@ -0,0 +1,77 @@
+package httprouter
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// TypedOptions represents the options for configuring a Typed handler.
+type TypedOptions struct {
+	StatusCode int
+}
+
+// WithSuccessStatus allows you to configure the status code of the response
+// when the handler succeeds.
+//
+// Default behavior is to respond with http.StatusOK.
+func WithSuccessStatus(statusCode int) func(opts *TypedOptions) {
+	return func(opts *TypedOptions) {
+		opts.StatusCode = statusCode
+	}
+}
+
+// Typed adapts a plain business function into a Handler.
+//
+// The request is bound into Req like Bind does, except that the body is
+// optional, and Req is validated. The value returned by fn is sent to the
+// client with Respond, while the error, if any, is returned to the router
+// error handling.
+//
+//	type GetCardRequest struct {
+//		ID     string `path:"id" validate:"required"`
+//		Expand bool   `query:"expand"`
+//	}
+//
+//	r.Get("/cards/{id}", httprouter.Typed(cards.Get))
+func Typed[Req, Resp any](fn func(ctx context.Context, req Req) (Resp, error), optFns ...func(opts *TypedOptions)) Handler {
+	opts := TypedOptions{
+		StatusCode: http.StatusOK,
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req Req
+		if err := bind(r, &req, false); err != nil {
+			return err
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			return err
+		}
+
+		if isNil(resp) {
+			return Respond(w, r, opts.StatusCode, nil)
+		}
+
+		return Respond(w, r, opts.StatusCode, resp)
+	}
+}
+
+// isNil reports whether v is nil or a nil pointer, map, slice or interface.
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return false
+	}
+}
@@ -0,0 +1,147 @@
+package httprouter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+type createCardRequest struct {
+	UserID    string `path:"user_id" validate:"required"`
+	Brand     string `json:"brand" validate:"required,oneof=visa mastercard"`
+	DryRun    bool   `query:"dry_run"`
+	Limit     *int   `query:"limit"`
+	RequestID string `header:"X-Request-Id"`
+}
+
+type card struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Brand     string `json:"brand"`
+	DryRun    bool   `json:"dry_run"`
+	Limit     int    `json:"limit,omitempty"`
+	RequestID string `json:"request_id"`
+}
+
+func createCard(_ context.Context, req createCardRequest) (card, error) {
+	if req.UserID == "blocked" {
+		return card{}, httprouter.NewError(http.StatusConflict, "user is blocked")
+	}
+
+	c := card{ID: "crd-1", UserID: req.UserID, Brand: req.Brand, DryRun: req.DryRun, RequestID: req.RequestID}
+	if req.Limit != nil {
+		c.Limit = *req.Limit
+	}
+
+	return c, nil
+}
+
+type getCardRequest struct {
+	ID string `path:"id" validate:"required"`
+}
+
+func getCard(_ context.Context, req getCardRequest) (*card, error) {
+	if req.ID == "missing" {
+		return nil, nil
+	}
+
+	return &card{ID: req.ID}, nil
+}
+
+func TestTyped(t *testing.T) {
+	r := httprouter.New()
+	r.Post("/users/{user_id}/cards", httprouter.Typed(createCard, httprouter.WithSuccessStatus(http.StatusCreated)))
+	r.Get("/cards/{id}", httprouter.Typed(getCard))
+
+	tests := []struct {
+		name         string
+		method       string
+		target       string
+		body         string
+		headers      map[string]string
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "binds body, path, query and headers",
+			method:       http.MethodPost,
+			target:       "/users/usr-1/cards?dry_run=true&limit=10",
+			body:         `{"brand":"visa"}`,
+			headers:      map[string]string{"X-Request-Id": "req-1"},
+			expectedCode: http.StatusCreated,
+			expectedBody: `{"id":"crd-1","user_id":"usr-1","brand":"visa","dry_run":true,"limit":10,"request_id":"req-1"}`,
+		},
+		{
+			name:         "validates the bound request",
+			method:       http.MethodPost,
+			target:       "/users/usr-1/cards",
+			body:         `{"brand":"amex"}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedBody: `{"message":"validation_error: invalid fields: Brand","error":"unprocessable_entity","status":422,` +
+				`"violations":[{"field":"brand","tag":"oneof","param":"visa mastercard","value":"amex","message":"brand must be one of [visa mastercard]"}]}`,
+		},
+		{
+			name:         "rejects malformed parameters",
+			method:       http.MethodPost,
+			target:       "/users/usr-1/cards?dry_run=maybe",
+			body:         `{"brand":"visa"}`,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"message":"invalid query parameter dry_run: \"maybe\"","error":"bad_request","status":400}`,
+		},
+		{
+			name:         "rejects unsupported media types",
+			method:       http.MethodPost,
+			target:       "/users/usr-1/cards",
+			body:         `brand=visa`,
+			headers:      map[string]string{"Content-Type": "text/plain"},
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedBody: `{"message":"unsupported media type: text/plain","error":"unsupported_media_type","status":415}`,
+		},
+		{
+			name:         "returns the handler error",
+			method:       http.MethodPost,
+			target:       "/users/blocked/cards",
+			body:         `{"brand":"visa"}`,
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"message":"user is blocked","error":"conflict","status":409}`,
+		},
+		{
+			name:         "binds requests without body",
+			method:       http.MethodGet,
+			target:       "/cards/crd-1",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"id":"crd-1","user_id":"","brand":"","dry_run":false,"request_id":""}`,
+		},
+		{
+			name:         "responds nil values without body",
+			method:       http.MethodGet,
+			target:       "/cards/missing",
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			res := httptest.NewRecorder()
+
+			r.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.expectedCode, res.Code)
+			if tt.expectedBody == "" {
+				assert.Empty(t, res.Body.String())
+				return
+			}
+			assert.JSONEq(t, tt.expectedBody, res.Body.String())
+		})
+	}
+}
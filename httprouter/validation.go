@@ -0,0 +1,211 @@
+package httprouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	estranslations "github.com/go-playground/validator/v10/translations/es"
+)
+
+var (
+	_validate   = newValidate()
+	_translator = ut.New(en.New())
+)
+
+// Violation describes a field of a bound request that failed validation.
+type Violation struct {
+	// Field is the path of the field, named after its json, form, query,
+	// path or header tag.
+	Field string `json:"field"`
+	// Tag is the validation tag that failed, like "required" or "max".
+	Tag string `json:"tag"`
+	// Param is the parameter of the validation tag, like "10" for "max=10".
+	Param string `json:"param,omitempty"`
+	// Value is the rejected value.
+	Value any `json:"value"`
+	// Message is a human readable description of the violation, translated
+	// according to the "Accept-Language" header of the request.
+	Message string `json:"message"`
+}
+
+// RegisterLanguage registers the translations used for the messages of the
+// violations when the "Accept-Language" header of the request asks for locale.
+// English and Spanish are registered by default, English being the fallback.
+//
+// Translations of the built-in validation tags are provided by the
+// translations packages of the validator:
+//
+//	import (
+//		"github.com/go-playground/locales/pt"
+//		pttranslations "github.com/go-playground/validator/v10/translations/pt"
+//	)
+//
+//	err := httprouter.RegisterLanguage(pt.New(), pttranslations.RegisterDefaultTranslations)
+//
+// It is not safe for concurrent use, register languages before serving requests.
+func RegisterLanguage(locale locales.Translator, register func(v *validator.Validate, trans ut.Translator) error) error {
+	if err := _translator.AddTranslator(locale, true); err != nil {
+		return err
+	}
+
+	trans, _ := _translator.GetTranslator(locale.Locale())
+	return register(_validate, trans)
+}
+
+// RegisterValidation adds a custom validation tag to the validator used by Bind.
+// Messages maps a locale, like "en" or "es", to the message of the violation,
+// where "{0}" is replaced with the field and "{1}" with the param of the tag.
+//
+//	err := httprouter.RegisterValidation("cuit", isCUIT, map[string]string{
+//		"en": "{0} must be a valid CUIT",
+//		"es": "{0} debe ser un CUIT válido",
+//	})
+//
+// It is not safe for concurrent use, register validations before serving requests.
+func RegisterValidation(tag string, fn validator.Func, messages map[string]string) error {
+	if err := _validate.RegisterValidation(tag, fn); err != nil {
+		return err
+	}
+
+	for locale, message := range messages {
+		trans, found := _translator.GetTranslator(locale)
+		if !found {
+			return fmt.Errorf("registering validation %s: unknown language %s", tag, locale)
+		}
+
+		err := _validate.RegisterTranslation(tag, trans,
+			func(trans ut.Translator) error {
+				return trans.Add(tag, message, true)
+			},
+			func(trans ut.Translator, fe validator.FieldError) string {
+				message, _ := trans.T(tag, fe.Field(), fe.Param())
+				return message
+			},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newValidate() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(fieldName)
+	return v
+}
+
+func init() {
+	mustRegisterLanguage(en.New(), entranslations.RegisterDefaultTranslations)
+	mustRegisterLanguage(es.New(), estranslations.RegisterDefaultTranslations)
+}
+
+func mustRegisterLanguage(locale locales.Translator, register func(v *validator.Validate, trans ut.Translator) error) {
+	if err := RegisterLanguage(locale, register); err != nil {
+		panic(err)
+	}
+}
+
+// fieldName names the field after the first tag it is bound with, falling
+// back to the name of the field. Fields ignored by encoding/json may still be
+// bound from a request parameter.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", _tagForm, _tagQuery, _tagPath, _tagHeader} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+func validateStruct(r *http.Request, destination any) error {
+	if err := _validate.StructCtx(r.Context(), destination); err != nil {
+		var invalidValidationError *validator.InvalidValidationError
+		if errors.As(err, &invalidValidationError) {
+			// We choose to ignore errors related to types
+			// that can't be validated like time.Time and slices.
+			return nil
+		}
+
+		message := err.Error()
+
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			return NewErrorf(http.StatusUnprocessableEntity, "validation_error: %s", message)
+		}
+
+		trans := translatorFor(r)
+		fields := make([]string, 0, len(validationErrs))
+		violations := make([]Violation, 0, len(validationErrs))
+		for _, v := range validationErrs {
+			fields = append(fields, v.StructField())
+			violations = append(violations, Violation{
+				Field:   fieldPath(v.Namespace()),
+				Tag:     v.Tag(),
+				Param:   v.Param(),
+				Value:   v.Value(),
+				Message: translate(v, trans),
+			})
+		}
+
+		webErr := newError(http.StatusUnprocessableEntity, "validation_error: invalid fields: "+strings.Join(fields, ","))
+		webErr.Violations = violations
+		return webErr
+	}
+
+	return nil
+}
+
+// translate returns the message of fe in the language of trans, falling back
+// to English when the tag has no translation for that language.
+func translate(fe validator.FieldError, trans ut.Translator) string {
+	if message := fe.Translate(trans); message != fe.Error() {
+		return message
+	}
+	return fe.Translate(_translator.GetFallback())
+}
+
+// fieldPath strips the name of the validated struct from namespace.
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+	return path
+}
+
+// translatorFor returns the translator of the first language of the
+// "Accept-Language" header of the request that is registered.
+func translatorFor(r *http.Request) ut.Translator {
+	var candidates []string
+	for _, lang := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		lang, _, _ = strings.Cut(lang, ";")
+		lang = strings.ReplaceAll(strings.TrimSpace(lang), "-", "_")
+		if lang == "" || lang == "*" {
+			continue
+		}
+
+		candidates = append(candidates, lang)
+		if base, _, found := strings.Cut(lang, "_"); found {
+			candidates = append(candidates, base)
+		}
+	}
+
+	trans, _ := _translator.FindTranslator(candidates...)
+	return trans
+}
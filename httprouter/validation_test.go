@@ -0,0 +1,119 @@
+package httprouter_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/validator/v10"
+	pttranslations "github.com/go-playground/validator/v10/translations/pt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+type address struct {
+	Street string `json:"street" validate:"required"`
+}
+
+type customer struct {
+	Name    string  `json:"name" validate:"required"`
+	Age     int     `json:"age" validate:"gte=18"`
+	TaxID   string  `json:"tax_id" validate:"omitempty,tax_id"`
+	Address address `json:"address"`
+	Page    int     `query:"page" validate:"max=100"`
+}
+
+func TestBind_Violations(t *testing.T) {
+	err := httprouter.RegisterValidation("tax_id", func(fl validator.FieldLevel) bool {
+		return len(fl.Field().String()) == 11
+	}, map[string]string{
+		"en": "{0} must be a valid tax id",
+		"es": "{0} debe ser un identificador fiscal válido",
+	})
+	require.NoError(t, err)
+	require.NoError(t, httprouter.RegisterLanguage(pt.New(), pttranslations.RegisterDefaultTranslations))
+
+	body := `{"age":16,"tax_id":"123","address":{}}`
+
+	tt := []struct {
+		name               string
+		acceptLanguage     string
+		expectedViolations []httprouter.Violation
+	}{
+		{
+			name: "should default to english",
+			expectedViolations: []httprouter.Violation{
+				{Field: "name", Tag: "required", Value: "", Message: "name is a required field"},
+				{Field: "age", Tag: "gte", Param: "18", Value: 16, Message: "age must be 18 or greater"},
+				{Field: "tax_id", Tag: "tax_id", Value: "123", Message: "tax_id must be a valid tax id"},
+				{Field: "address.street", Tag: "required", Value: "", Message: "street is a required field"},
+				{Field: "page", Tag: "max", Param: "100", Value: 500, Message: "page must be 100 or less"},
+			},
+		},
+		{
+			name:           "should translate to spanish",
+			acceptLanguage: "es-AR,es;q=0.9,en;q=0.8",
+			expectedViolations: []httprouter.Violation{
+				{Field: "name", Tag: "required", Value: "", Message: "name es un campo requerido"},
+				{Field: "age", Tag: "gte", Param: "18", Value: 16, Message: "age debe ser 18 o mayor"},
+				{Field: "tax_id", Tag: "tax_id", Value: "123", Message: "tax_id debe ser un identificador fiscal válido"},
+				{Field: "address.street", Tag: "required", Value: "", Message: "street es un campo requerido"},
+				{Field: "page", Tag: "max", Param: "100", Value: 500, Message: "page debe ser 100 o menos"},
+			},
+		},
+		{
+			name:           "should fall back to english for untranslated tags",
+			acceptLanguage: "pt",
+			expectedViolations: []httprouter.Violation{
+				{Field: "name", Tag: "required", Value: "", Message: "name é obrigatório"},
+				{Field: "age", Tag: "gte", Param: "18", Value: 16, Message: "age deve ser maior ou igual a 18"},
+				{Field: "tax_id", Tag: "tax_id", Value: "123", Message: "tax_id must be a valid tax id"},
+				{Field: "address.street", Tag: "required", Value: "", Message: "street é obrigatório"},
+				{Field: "page", Tag: "max", Param: "100", Value: 500, Message: "page deve ser 100 ou menos"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/customers?page=500", strings.NewReader(body))
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+
+			err := httprouter.Bind(req, &customer{})
+
+			var webErr *httprouter.Error
+			require.True(t, errors.As(err, &webErr))
+			require.Equal(t, http.StatusUnprocessableEntity, webErr.StatusCode)
+			require.Equal(t, "validation_error: invalid fields: Name,Age,TaxID,Street,Page", webErr.Message)
+			require.Equal(t, tc.expectedViolations, webErr.Violations)
+		})
+	}
+}
+
+func TestBind_ViolationsOfFieldsIgnoredByJSON(t *testing.T) {
+	var destination struct {
+		Page int `json:"-" query:"page" validate:"min=1"`
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/customers?page=0", nil)
+
+	err := httprouter.Bind(req, &destination)
+
+	var webErr *httprouter.Error
+	require.True(t, errors.As(err, &webErr))
+	require.Equal(t, http.StatusUnprocessableEntity, webErr.StatusCode)
+	require.Len(t, webErr.Violations, 1)
+	require.Equal(t, "page", webErr.Violations[0].Field)
+	require.Equal(t, "page must be 1 or greater", webErr.Violations[0].Message)
+}
+
+func TestRegisterValidation_UnknownLanguage(t *testing.T) {
+	err := httprouter.RegisterValidation("always", func(fl validator.FieldLevel) bool {
+		return true
+	}, map[string]string{"xx": "{0}"})
+	require.EqualError(t, err, "registering validation always: unknown language xx")
+}
@@ -0,0 +1,253 @@
+package httprouter
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	_headerDeprecation = "Deprecation"
+	_headerSunset      = "Sunset"
+	_headerLink        = "Link"
+)
+
+type versionKey struct{}
+
+// VersioningConfig represents the configuration of the selection of the
+// version of the requests whose path has no version prefix.
+type VersioningConfig struct {
+	// Header is the request header holding the version, like "X-API-Version".
+	Header string
+	// AcceptParam is the parameter of the media types of the "Accept" header
+	// holding the version, like "version" in "application/json; version=2".
+	AcceptParam string
+	// Default is the version of the requests that select none.
+	Default string
+}
+
+// WithVersioning allows you to configure the router to select the version of
+// the requests whose path has no version prefix by a request header, the
+// "Accept" header or a default version. The requested version is matched
+// against the versions registered with Version on the router, ignoring a
+// leading "v", so "2" selects version "v2".
+//
+// Default behavior is to select versions by their path prefix only.
+func WithVersioning(config VersioningConfig) func(options *Config) {
+	return func(opt *Config) {
+		opt.Versioning = &config
+	}
+}
+
+// VersionOptions represents the options for configuring a version of the API.
+type VersionOptions struct {
+	Deprecated   bool
+	DeprecatedAt time.Time
+	Sunset       time.Time
+	Link         string
+}
+
+// WithDeprecation allows you to configure the version as deprecated since the
+// given date, announced by the "Deprecation" header of its responses. A zero
+// date announces the deprecation without a date.
+//
+// Default behavior is to not deprecate the version.
+func WithDeprecation(at time.Time) func(opts *VersionOptions) {
+	return func(opts *VersionOptions) {
+		opts.Deprecated = true
+		opts.DeprecatedAt = at
+	}
+}
+
+// WithSunset allows you to configure the date the version stops being served,
+// announced by the "Sunset" header of its responses, as defined by RFC 8594.
+//
+// Default behavior is to not announce any sunset.
+func WithSunset(at time.Time) func(opts *VersionOptions) {
+	return func(opts *VersionOptions) {
+		opts.Sunset = at
+	}
+}
+
+// WithDeprecationLink allows you to configure the URL of the documentation of
+// the deprecation, like a migration guide, sent in the "Link" header of the
+// responses of a deprecated version.
+func WithDeprecationLink(url string) func(opts *VersionOptions) {
+	return func(opts *VersionOptions) {
+		opts.Link = url
+	}
+}
+
+// VersionFromContext returns the version of the route serving the request,
+// if it was registered with Version.
+func VersionFromContext(ctx context.Context) (string, bool) {
+	version, ok := ctx.Value(versionKey{}).(string)
+	return version, ok
+}
+
+// Version creates a new Mux with the routes of the given version of the API
+// and mounts it along the "/{version}" pattern as a subrouter.
+//
+//	r.Version("v1", func(r httprouter.Router) {
+//		r.Get("/cards/{id}", getCardV1)
+//	}, httprouter.WithDeprecation(deprecatedAt), httprouter.WithSunset(sunsetAt))
+//
+//	r.Version("v2", func(r httprouter.Router) {
+//		r.Get("/cards/{id}", getCard)
+//	})
+//
+// The requests whose path has no version prefix are also served by the
+// versions of the router created with New when it is configured with
+// WithVersioning, while the versions of the routers created with Route are
+// only selected by their path prefix.
+func (r *Router) Version(version string, fn func(r Router), optFns ...func(opts *VersionOptions)) *Router {
+	if fn == nil {
+		panic(fmt.Sprintf("httrouter: attempting to Version() a nil subrouter on '%s'", version))
+	}
+
+	var opts VersionOptions
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	v := &apiVersion{name: version, options: opts}
+
+	mux := chi.NewRouter()
+	mux.Use(v.middleware)
+
+	subRouter := r.child(mux)
+	subRouter.version = v
+	subRouter.versions = nil
+	subRouter.options = newPatternSet()
+	fn(*subRouter)
+	r.mux.Mount("/"+version, mux)
+
+	if r.versions != nil {
+		r.versions.add(v)
+	}
+
+	return subRouter
+}
+
+// apiVersion is a version of the API registered with Router.Version.
+type apiVersion struct {
+	name    string
+	options VersionOptions
+}
+
+// middleware announces the deprecation of the version and stores the version
+// in the context of the request.
+func (v *apiVersion) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v.options.Deprecated {
+			if v.options.DeprecatedAt.IsZero() {
+				w.Header().Set(_headerDeprecation, "true")
+			} else {
+				w.Header().Set(_headerDeprecation, "@"+strconv.FormatInt(v.options.DeprecatedAt.Unix(), 10))
+			}
+
+			if v.options.Link != "" {
+				w.Header().Add(_headerLink, "<"+v.options.Link+`>; rel="deprecation"; type="text/html"`)
+			}
+		}
+
+		if !v.options.Sunset.IsZero() {
+			w.Header().Set(_headerSunset, v.options.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), versionKey{}, v.name)))
+	})
+}
+
+// versionSet holds the versions registered on a router created with New.
+type versionSet struct {
+	mu       sync.RWMutex
+	versions map[string]*apiVersion
+}
+
+func newVersionSet() *versionSet {
+	return &versionSet{versions: make(map[string]*apiVersion)}
+}
+
+func (s *versionSet) add(v *apiVersion) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.versions[normalizeVersion(v.name)] = v
+}
+
+func (s *versionSet) lookup(version string) (*apiVersion, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	v, ok := s.versions[normalizeVersion(version)]
+	return v, ok
+}
+
+func normalizeVersion(version string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "v")
+}
+
+// negotiateVersion routes the requests whose path has no version prefix to
+// the routes of the version they select, when the version has a route
+// matching them. Any other request is routed as it is.
+func (r *Router) negotiateVersion(config VersioningConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			rctx := chi.RouteContext(req.Context())
+			requested := requestedVersion(req, config)
+			if rctx == nil || requested == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			if v, ok := r.versions.lookup(requested); ok {
+				path := rctx.RoutePath
+				if path == "" {
+					path = req.URL.Path
+					if req.URL.RawPath != "" {
+						path = req.URL.RawPath
+					}
+				}
+
+				versioned := "/" + v.name + path
+				if r.mux.Match(chi.NewRouteContext(), req.Method, versioned) {
+					rctx.RoutePath = versioned
+				}
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// requestedVersion returns the version selected by the request header, the
+// "Accept" header or the default version, in that order.
+func requestedVersion(r *http.Request, config VersioningConfig) string {
+	if config.Header != "" {
+		if version := r.Header.Get(config.Header); version != "" {
+			return version
+		}
+	}
+
+	if config.AcceptParam != "" {
+		for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+			_, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			if version := params[config.AcceptParam]; version != "" {
+				return version
+			}
+		}
+	}
+
+	return config.Default
+}
@@ -0,0 +1,198 @@
+package httprouter_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+func versionedRouter(optFns ...func(options *httprouter.Config)) *httprouter.Router {
+	h := func(w http.ResponseWriter, r *http.Request) error {
+		version, _ := httprouter.VersionFromContext(r.Context())
+		return httprouter.RespondJSON(w, http.StatusOK, version+" "+httprouter.URLParam(r, "id"))
+	}
+
+	//revive:disable:unused-parameter
+	liveness := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	//revive:enable:unused-parameter
+
+	r := httprouter.New(append(optFns, httprouter.WithHealthCheckLivenessHandler(liveness))...)
+	r.Version("v1", func(r httprouter.Router) {
+		r.Get("/cards/{id}", h)
+		r.Get("/legacy", h)
+	},
+		httprouter.WithDeprecation(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		httprouter.WithSunset(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)),
+		httprouter.WithDeprecationLink("https://developers.pomelo.la/migrations/v2"),
+	)
+	r.Version("v2", func(r httprouter.Router) {
+		r.Get("/cards/{id}", h)
+	})
+
+	return r
+}
+
+func TestRouterVersion(t *testing.T) {
+	r := versionedRouter()
+
+	tt := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantHeader http.Header
+	}{
+		{
+			name:       "deprecated version",
+			path:       "/v1/cards/crd-1",
+			wantStatus: http.StatusOK,
+			wantBody:   `"v1 crd-1"`,
+			wantHeader: http.Header{
+				"Deprecation": {"@1704067200"},
+				"Sunset":      {"Wed, 01 Jan 2025 00:00:00 GMT"},
+				"Link":        {`<https://developers.pomelo.la/migrations/v2>; rel="deprecation"; type="text/html"`},
+			},
+		},
+		{
+			name:       "current version",
+			path:       "/v2/cards/crd-1",
+			wantStatus: http.StatusOK,
+			wantBody:   `"v2 crd-1"`,
+			wantHeader: http.Header{},
+		},
+		{
+			name:       "route missing in version",
+			path:       "/v2/legacy",
+			wantStatus: http.StatusNotFound,
+			wantHeader: http.Header{},
+		},
+		{
+			name:       "no version prefix",
+			path:       "/cards/crd-1",
+			wantStatus: http.StatusNotFound,
+			wantHeader: http.Header{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, w.Body.String())
+			}
+			for _, header := range []string{"Deprecation", "Sunset", "Link"} {
+				assert.Equal(t, tc.wantHeader.Values(header), w.Header().Values(header), header)
+			}
+		})
+	}
+}
+
+func TestRouterVersion_Negotiation(t *testing.T) {
+	r := versionedRouter(httprouter.WithVersioning(httprouter.VersioningConfig{
+		Header:      "X-API-Version",
+		AcceptParam: "version",
+		Default:     "v2",
+	}))
+
+	tt := []struct {
+		name       string
+		path       string
+		header     http.Header
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "header",
+			path:       "/cards/crd-1",
+			header:     http.Header{"X-Api-Version": {"1"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"v1 crd-1"`,
+		},
+		{
+			name:       "accept media type parameter",
+			path:       "/legacy",
+			header:     http.Header{"Accept": {"application/json; version=v1"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"v1 "`,
+		},
+		{
+			name:       "default version",
+			path:       "/cards/crd-1",
+			wantStatus: http.StatusOK,
+			wantBody:   `"v2 crd-1"`,
+		},
+		{
+			name:       "version prefix wins",
+			path:       "/v2/cards/crd-1",
+			header:     http.Header{"X-Api-Version": {"1"}},
+			wantStatus: http.StatusOK,
+			wantBody:   `"v2 crd-1"`,
+		},
+		{
+			name:       "unknown version",
+			path:       "/cards/crd-1",
+			header:     http.Header{"X-Api-Version": {"9"}},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "route missing in version",
+			path:       "/legacy",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "unversioned route",
+			path:       "/liveness",
+			header:     http.Header{"X-Api-Version": {"1"}},
+			wantStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header = tc.header
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.wantStatus, w.Code)
+			if tc.wantBody != "" {
+				assert.Equal(t, tc.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouterVersion_Routes(t *testing.T) {
+	r := versionedRouter()
+
+	routes, err := r.Routes()
+	require.NoError(t, err)
+
+	versions := make(map[string]string)
+	for _, route := range routes {
+		versions[route.Route] = route.Version
+	}
+	assert.Equal(t, map[string]string{
+		"/liveness":      "",
+		"/v1/cards/{id}": "v1",
+		"/v1/legacy":     "v1",
+		"/v2/cards/{id}": "v2",
+	}, versions)
+
+	doc, err := r.OpenAPI(httprouter.OpenAPIConfig{})
+	require.NoError(t, err)
+	assert.True(t, doc.Paths["/v1/cards/{id}"]["get"].Deprecated)
+	assert.False(t, doc.Paths["/v2/cards/{id}"]["get"].Deprecated)
+}
@@ -0,0 +1,211 @@
+package httprouter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	_defaultWebSocketCloseGracePeriod = 5 * time.Second
+	_webSocketWriteWait               = time.Second
+)
+
+// WebSocketOptions represents the options for configuring a WebSocket handler.
+type WebSocketOptions struct {
+	CheckOrigin      func(r *http.Request) bool
+	Subprotocols     []string
+	CloseGracePeriod time.Duration
+	MeterProvider    metric.MeterProvider
+}
+
+// WithCheckOrigin allows you to configure the function that validates the
+// "Origin" header of the upgrade requests.
+//
+// Default behavior is to reject cross-origin requests.
+func WithCheckOrigin(checkOrigin func(r *http.Request) bool) func(opts *WebSocketOptions) {
+	return func(opts *WebSocketOptions) {
+		opts.CheckOrigin = checkOrigin
+	}
+}
+
+// WithSubprotocols allows you to configure the subprotocols supported by the
+// server, in order of preference.
+//
+// Default behavior is to negotiate no subprotocol.
+func WithSubprotocols(subprotocols ...string) func(opts *WebSocketOptions) {
+	return func(opts *WebSocketOptions) {
+		opts.Subprotocols = subprotocols
+	}
+}
+
+// WithCloseGracePeriod allows you to configure how long a connection is
+// given to complete the closing handshake, and how long the handler is given
+// to return once the server begins to shut down, before the connection is closed.
+//
+// Default behavior is to wait 5 seconds.
+func WithCloseGracePeriod(gracePeriod time.Duration) func(opts *WebSocketOptions) {
+	return func(opts *WebSocketOptions) {
+		opts.CloseGracePeriod = gracePeriod
+	}
+}
+
+// WithWebSocketMeterProvider allows you to configure the MeterProvider used
+// to export the websocket.connections.active metric.
+//
+// Default behavior is to use the global MeterProvider.
+func WithWebSocketMeterProvider(meterProvider metric.MeterProvider) func(opts *WebSocketOptions) {
+	return func(opts *WebSocketOptions) {
+		opts.MeterProvider = meterProvider
+	}
+}
+
+// WebSocket returns a Handler that upgrades the request to a WebSocket
+// connection and serves it with fn. Being a Handler, the route goes through
+// the middleware chain of the router like any other.
+//
+// The context given to fn is canceled when the server started with Run
+// begins to shut down, after sending a "going away" close message to the
+// client. Once fn returns, the connection is closed with a normal closure,
+// or with an internal error closure if fn returns an error, which is also
+// recorded on the active span.
+//
+// Active connections are counted in the websocket.connections.active metric.
+//
+//	r.Get("/ws/notifications", httprouter.WebSocket(func(ctx context.Context, conn *websocket.Conn) error {
+//		for {
+//			select {
+//			case <-ctx.Done():
+//				return nil
+//			case n := <-notifications:
+//				if err := conn.WriteJSON(n); err != nil {
+//					return err
+//				}
+//			}
+//		}
+//	}))
+func WebSocket(fn func(ctx context.Context, conn *websocket.Conn) error, optFns ...func(opts *WebSocketOptions)) Handler {
+	opts := WebSocketOptions{
+		CloseGracePeriod: _defaultWebSocketCloseGracePeriod,
+		MeterProvider:    otel.GetMeterProvider(),
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	active, activeErr := opts.MeterProvider.Meter(_instrumentationName).
+		Int64UpDownCounter("websocket.connections.active")
+
+	return func(w http.ResponseWriter, r *http.Request) error {
+		if activeErr != nil {
+			return activeErr
+		}
+
+		// Handshake errors are returned to the router instead of being written
+		// by the upgrader, so they go through the ErrorHandlerFunc.
+		status := http.StatusBadRequest
+		upgrader := websocket.Upgrader{
+			CheckOrigin:  opts.CheckOrigin,
+			Subprotocols: opts.Subprotocols,
+			Error: func(_ http.ResponseWriter, _ *http.Request, s int, _ error) {
+				status = s
+			},
+		}
+
+		// The connection is tracked before being hijacked, so a shutdown that
+		// starts meanwhile waits for it.
+		done := trackConnection(r.Context())
+		defer done()
+
+		conn, err := upgrader.Upgrade(hijackableWriter{w}, r, nil)
+		if err != nil {
+			var handshakeErr websocket.HandshakeError
+			if errors.As(err, &handshakeErr) {
+				return NewError(status, err.Error())
+			}
+			return err
+		}
+
+		attrs := metric.WithAttributes(attribute.String("route", chi.RouteContext(r.Context()).RoutePattern()))
+		active.Add(r.Context(), 1, attrs)
+		defer active.Add(context.WithoutCancel(r.Context()), -1, attrs)
+
+		serveWebSocket(r, conn, fn, opts.CloseGracePeriod)
+		return nil
+	}
+}
+
+func serveWebSocket(r *http.Request, conn *websocket.Conn, fn func(ctx context.Context, conn *websocket.Conn) error, gracePeriod time.Duration) {
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var closeOnce sync.Once
+	closeWith := func(code int, text string) {
+		closeOnce.Do(func() {
+			msg := websocket.FormatCloseMessage(code, text)
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(_webSocketWriteWait))
+		})
+	}
+
+	served := make(chan struct{})
+	defer close(served)
+
+	go func() {
+		select {
+		case <-shuttingDown(r.Context()):
+			closeWith(websocket.CloseGoingAway, "server shutting down")
+			cancel()
+		case <-served:
+			return
+		}
+
+		// Handlers that ignore the cancellation are cut off once the grace
+		// period is over.
+		select {
+		case <-time.After(gracePeriod):
+			_ = conn.Close()
+		case <-served:
+		}
+	}()
+
+	err := fn(ctx, conn)
+
+	var closeErr *websocket.CloseError
+	if err != nil && !errors.As(err, &closeErr) {
+		trace.SpanFromContext(r.Context()).RecordError(err)
+		closeWith(websocket.CloseInternalServerErr, "")
+	} else {
+		closeWith(websocket.CloseNormalClosure, "")
+	}
+
+	// Wait for the client to complete the closing handshake.
+	_ = conn.SetReadDeadline(time.Now().Add(gracePeriod))
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			return
+		}
+	}
+}
+
+// hijackableWriter exposes the http.Hijacker of the response writers wrapped
+// by the middlewares, as reported by their Unwrap method.
+type hijackableWriter struct {
+	http.ResponseWriter
+}
+
+func (w hijackableWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
@@ -0,0 +1,206 @@
+package httprouter_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"github.com/pomelo-la/go-toolkit/httprouter"
+)
+
+// unwrapOnlyWriter hides the http.Hijacker of the wrapped writer behind Unwrap.
+type unwrapOnlyWriter struct {
+	w http.ResponseWriter
+}
+
+func (u unwrapOnlyWriter) Header() http.Header         { return u.w.Header() }
+func (u unwrapOnlyWriter) Write(b []byte) (int, error) { return u.w.Write(b) }
+func (u unwrapOnlyWriter) WriteHeader(code int)        { u.w.WriteHeader(code) }
+func (u unwrapOnlyWriter) Unwrap() http.ResponseWriter { return u.w }
+
+func wsURL(server *httptest.Server, path string) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http") + path
+}
+
+func activeConnections(t *testing.T, reader *sdkmetric.ManualReader) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	require.Len(t, rm.ScopeMetrics[0].Metrics, 1)
+
+	active := rm.ScopeMetrics[0].Metrics[0]
+	require.Equal(t, "websocket.connections.active", active.Name)
+
+	var total int64
+	for _, dp := range active.Data.(metricdata.Sum[int64]).DataPoints {
+		total += dp.Value
+	}
+	return total
+}
+
+func TestWebSocket(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	r := streamingRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(unwrapOnlyWriter{w}, r)
+		})
+	})
+	r.Get("/echo", httprouter.WebSocket(func(ctx context.Context, conn *websocket.Conn) error {
+		for {
+			typ, msg, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if err := conn.WriteMessage(typ, msg); err != nil {
+				return err
+			}
+		}
+	}, httprouter.WithSubprotocols("echo"), httprouter.WithWebSocketMeterProvider(mp)))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"echo"}}
+	conn, resp, err := dialer.Dial(wsURL(server, "/echo"), http.Header{"Accept-Encoding": {"gzip"}})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	assert.Equal(t, "echo", conn.Subprotocol())
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+	typ, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, websocket.TextMessage, typ)
+	assert.Equal(t, "hello", string(msg))
+
+	assert.EqualValues(t, 1, activeConnections(t, reader))
+
+	// Closing the connection ends the handler.
+	require.NoError(t, conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")))
+
+	assert.Eventually(t, func() bool {
+		return activeConnections(t, reader) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWebSocket_HandlerError(t *testing.T) {
+	r := httprouter.New()
+	r.Get("/ws", httprouter.WebSocket(func(ctx context.Context, conn *websocket.Conn) error {
+		return errors.New("boom")
+	}))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL(server, "/ws"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseInternalServerErr), err)
+}
+
+func TestWebSocket_HandshakeError(t *testing.T) {
+	r := httprouter.New()
+	r.Get("/ws", httprouter.WebSocket(func(ctx context.Context, conn *websocket.Conn) error {
+		return nil
+	}))
+
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	tt := []struct {
+		name       string
+		header     http.Header
+		wantStatus int
+	}{
+		{
+			name:       "not a websocket request",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "cross origin request",
+			header: http.Header{
+				"Connection":            {"Upgrade"},
+				"Upgrade":               {"websocket"},
+				"Sec-Websocket-Version": {"13"},
+				"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+				"Origin":                {"https://evil.example"},
+			},
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+			require.NoError(t, err)
+			req.Header = tc.header
+
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.wantStatus, resp.StatusCode)
+			assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func TestRun_WebSocketShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+
+	handlerDone := make(chan struct{})
+	r := httprouter.New()
+	r.Get("/ws", httprouter.WebSocket(func(ctx context.Context, conn *websocket.Conn) error {
+		defer close(handlerDone)
+		<-ctx.Done()
+		return nil
+	}, httprouter.WithCloseGracePeriod(100*time.Millisecond)))
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- httprouter.Run(ln, httprouter.DefaultTimeouts, r)
+	}()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+ln.Addr().String()+"/ws", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+
+	select {
+	case <-handlerDone:
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not canceled")
+	}
+
+	select {
+	case err := <-serverErr:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timeout")
+	}
+}
@@ -0,0 +1,22 @@
+/*
+Package transport provides an instrumented HTTP client for calling other
+services from within a web application.
+
+Welcome to the transport user guide. Here we will guide you through some
+practical examples of how to interact with the API.
+
+The HTTPRequester returned by NewHTTPClient propagates the W3C trace context
+and baggage stored in the request context (see httprouter.HeaderForwarder),
+opens a client span for every outgoing request, records http.client.* metrics
+through the global meter and publishes the http.client.conn_pools expvar
+polled by webapp.
+
+# Getting started
+
+	go get github.com/pomelo-la/go-toolkit/transport
+
+# Please visit and contribute to the community examples
+
+Usage examples https://github.com/pomelo-la/go-toolkit-examples
+*/
+package transport
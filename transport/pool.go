@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"context"
+	"expvar"
+	"net"
+	"sync"
+)
+
+// _connPoolsVarName is the name of the expvar polled by webapp in order to
+// report the http.client.conn_pool gauge.
+const _connPoolsVarName = "http.client.conn_pools"
+
+var (
+	_pools       = connPools{conns: make(map[string]map[string]int64)}
+	_publishOnce sync.Once
+)
+
+// connPools keeps track of the open connections of every pool, grouped by
+// pool name and network.
+type connPools struct {
+	mu    sync.Mutex
+	conns map[string]map[string]int64
+}
+
+func (p *connPools) add(pool, network string, delta int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.conns[pool]; !ok {
+		p.conns[pool] = make(map[string]int64)
+	}
+	p.conns[pool][network] += delta
+}
+
+func (p *connPools) snapshot() any {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info := make(map[string]map[string]int64, len(p.conns))
+	for pool, networks := range p.conns {
+		info[pool] = make(map[string]int64, len(networks))
+		for network, conns := range networks {
+			info[pool][network] = conns
+		}
+	}
+
+	return info
+}
+
+// publishConnPools exposes the connection pools information as an expvar,
+// unless another package has already published a variable with the same name.
+func publishConnPools() {
+	_publishOnce.Do(func() {
+		if expvar.Get(_connPoolsVarName) != nil {
+			return
+		}
+		expvar.Publish(_connPoolsVarName, expvar.Func(_pools.snapshot))
+	})
+}
+
+type dialContextFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// trackConns decorates dial so that every connection opened by it is accounted
+// to the given pool until it gets closed.
+func trackConns(pool string, dial dialContextFunc) dialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		_pools.add(pool, network, 1)
+
+		return &trackedConn{Conn: conn, pool: pool, network: network}, nil
+	}
+}
+
+// trackedConn is a net.Conn that releases its slot in the pool on Close.
+type trackedConn struct {
+	net.Conn
+	pool    string
+	network string
+	once    sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	c.once.Do(func() {
+		_pools.add(c.pool, c.network, -1)
+	})
+
+	return c.Conn.Close()
+}
@@ -0,0 +1,41 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/pomelo-la/go-toolkit/transport"
+)
+
+func TestConnPoolsExpvar(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	client, err := transport.NewHTTPClient("conn-pool")
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	v := expvar.Get("http.client.conn_pools")
+	require.NotNil(t, v)
+
+	var info map[string]map[string]int64
+	require.NoError(t, json.Unmarshal([]byte(v.String()), &info))
+	assert.EqualValues(t, 1, info["conn-pool"]["tcp"])
+
+	srv.CloseClientConnections()
+	srv.Close()
+}
@@ -0,0 +1,423 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	_instrumentationName = "github.com/pomelo-la/go-toolkit/transport"
+
+	_defaultTimeout             = 10 * time.Second
+	_defaultDialTimeout         = 5 * time.Second
+	_defaultKeepAlive           = 30 * time.Second
+	_defaultMaxIdleConns        = 100
+	_defaultMaxIdleConnsPerHost = 100
+	_defaultIdleConnTimeout     = 90 * time.Second
+)
+
+var (
+	// ErrInvalidClientName is an error that is returned when the client name provided is invalid.
+	ErrInvalidClientName = errors.New("client name cannot be empty or contains blank spaces")
+	// ErrCircuitOpen is an error that is returned when the circuit breaker of the client rejects a request.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// HTTPRequester is the interface that wraps the basic Do method.
+//
+// Do sends an HTTP request and returns an HTTP response, following the
+// same semantics as http.Client.Do.
+type HTTPRequester interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// CircuitBreaker represents a circuit breaker that can be used to control the
+// flow of outgoing requests, such as httprouter.RollingBreaker.
+type CircuitBreaker interface {
+	// Allow checks if a request is allowed to proceed.
+	Allow() bool
+	// Success signals a successful request to the circuit breaker.
+	Success()
+	// Failure signals a failed request to the circuit breaker.
+	Failure()
+}
+
+// Client is an instrumented HTTPRequester.
+//
+// Every outgoing request carries the W3C traceparent and baggage headers
+// found in the request context, is wrapped in a client span and is
+// recorded in the http.client.request.counter and
+// http.client.request.duration metrics. Every attempt made according to the
+// retry policy is added as an event to the span and recorded in the
+// http.client.request.attempt.counter metric.
+type Client struct {
+	name       string
+	client     *http.Client
+	retry      RetryOptions
+	breaker    CircuitBreaker
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	counter    metric.Int64Counter
+	attempts   metric.Int64Counter
+	duration   metric.Float64Histogram
+}
+
+// ClientOptions represents the options for configuring a Client.
+type ClientOptions struct {
+	Timeout             time.Duration
+	DialTimeout         time.Duration
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+	Retry               RetryOptions
+	CircuitBreaker      CircuitBreaker
+	TracerProvider      trace.TracerProvider
+	MeterProvider       metric.MeterProvider
+}
+
+// WithTimeout allows you to configure the time limit for requests made by
+// the client, including connection time, any redirects, and reading the
+// response body.
+//
+// Default behavior is to use a timeout of 10 seconds.
+func WithTimeout(timeout time.Duration) func(opts *ClientOptions) {
+	return func(opts *ClientOptions) {
+		opts.Timeout = timeout
+	}
+}
+
+// WithDialTimeout allows you to configure the maximum amount of time a dial
+// will wait for a connect to complete.
+//
+// Default behavior is to use a timeout of 5 seconds.
+func WithDialTimeout(timeout time.Duration) func(opts *ClientOptions) {
+	return func(opts *ClientOptions) {
+		opts.DialTimeout = timeout
+	}
+}
+
+// WithMaxIdleConns allows you to configure the maximum number of idle
+// (keep-alive) connections across all hosts and per host.
+//
+// Default behavior is to keep up to 100 idle connections, all of which may
+// belong to the same host.
+func WithMaxIdleConns(maxIdleConns, maxIdleConnsPerHost int) func(opts *ClientOptions) {
+	return func(opts *ClientOptions) {
+		opts.MaxIdleConns = maxIdleConns
+		opts.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	}
+}
+
+// WithIdleConnTimeout allows you to configure the maximum amount of time an
+// idle (keep-alive) connection will remain idle before closing itself.
+//
+// Default behavior is to use a timeout of 90 seconds.
+func WithIdleConnTimeout(timeout time.Duration) func(opts *ClientOptions) {
+	return func(opts *ClientOptions) {
+		opts.IdleConnTimeout = timeout
+	}
+}
+
+// WithCircuitBreaker allows you to configure the circuit breaker that guards
+// the requests made by the client. Transport errors and 5xx responses count
+// as failures, and requests rejected by the breaker fail with ErrCircuitOpen.
+//
+// Default behavior is to not use a circuit breaker.
+func WithCircuitBreaker(cb CircuitBreaker) func(opts *ClientOptions) {
+	return func(opts *ClientOptions) {
+		opts.CircuitBreaker = cb
+	}
+}
+
+// WithTracerProvider allows you to configure the trace.TracerProvider used
+// to create client spans.
+//
+// Default behavior is to use the global tracer provider.
+func WithTracerProvider(provider trace.TracerProvider) func(opts *ClientOptions) {
+	return func(opts *ClientOptions) {
+		opts.TracerProvider = provider
+	}
+}
+
+// WithMeterProvider allows you to configure the metric.MeterProvider used
+// to record the http.client.* metrics.
+//
+// Default behavior is to use the global meter provider.
+func WithMeterProvider(provider metric.MeterProvider) func(opts *ClientOptions) {
+	return func(opts *ClientOptions) {
+		opts.MeterProvider = provider
+	}
+}
+
+// NewHTTPClient instantiates a Client with sane defaults.
+//
+// The given name identifies the connection pool of the client, both in the
+// metrics attributes and in the http.client.conn_pools expvar.
+func NewHTTPClient(name string, optFns ...func(opts *ClientOptions)) (*Client, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+
+	opts := ClientOptions{
+		Timeout:             _defaultTimeout,
+		DialTimeout:         _defaultDialTimeout,
+		MaxIdleConns:        _defaultMaxIdleConns,
+		MaxIdleConnsPerHost: _defaultMaxIdleConnsPerHost,
+		IdleConnTimeout:     _defaultIdleConnTimeout,
+		Retry:               RetryOptions{MaxAttempts: 1},
+		TracerProvider:      otel.GetTracerProvider(),
+		MeterProvider:       otel.GetMeterProvider(),
+	}
+	for _, fn := range optFns {
+		fn(&opts)
+	}
+
+	meter := opts.MeterProvider.Meter(_instrumentationName)
+
+	counter, err := meter.Int64Counter("http.client.request.counter")
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := meter.Int64Counter("http.client.request.attempt.counter")
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram("http.client.request.duration")
+	if err != nil {
+		return nil, err
+	}
+
+	publishConnPools()
+
+	return &Client{
+		name: name,
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: newTransport(name, opts),
+		},
+		retry:      opts.Retry,
+		breaker:    opts.CircuitBreaker,
+		tracer:     opts.TracerProvider.Tracer(_instrumentationName),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+		counter:    counter,
+		attempts:   attempts,
+		duration:   duration,
+	}, nil
+}
+
+// Do sends an HTTP request and returns an HTTP response.
+//
+// The request is cloned before injecting the trace context headers, so the
+// given request is never modified. Requests are retried according to the
+// retry policy of the client; requests with a body are only retried when
+// req.GetBody is set, as http.NewRequest does for in-memory bodies.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx, span := c.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", req.URL.Redacted()),
+			attribute.String("server.address", req.URL.Host),
+			attribute.String("client", c.name),
+		))
+	defer span.End()
+
+	start := time.Now()
+	resp, err := c.guard(ctx, span, req, start)
+	if err != nil {
+		c.recordRequest(ctx, 0, start, req)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	c.recordRequest(ctx, resp.StatusCode, start, req)
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+
+	return resp, nil
+}
+
+// guard sends the request through the circuit breaker of the client, if any.
+func (c *Client) guard(ctx context.Context, span trace.Span, req *http.Request, start time.Time) (*http.Response, error) {
+	if c.breaker == nil {
+		return c.do(ctx, span, req, start)
+	}
+
+	if !c.breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := c.do(ctx, span, req, start)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		c.breaker.Failure()
+	} else {
+		c.breaker.Success()
+	}
+
+	return resp, err
+}
+
+func (c *Client) do(ctx context.Context, span trace.Span, req *http.Request, start time.Time) (*http.Response, error) {
+	canRetry := c.retry.canRetry(req)
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(ctx, req, attempt)
+		if !canRetry || attempt >= c.retry.MaxAttempts || !c.retry.shouldRetry(ctx, resp, err) {
+			c.recordAttempt(ctx, span, req, attempt, resp, err, 0)
+			return resp, err
+		}
+
+		backoff := c.retry.backoff(attempt, resp)
+		if c.retry.exhausted(ctx, start, backoff) {
+			c.recordAttempt(ctx, span, req, attempt, resp, err, 0)
+			return resp, err
+		}
+
+		c.recordAttempt(ctx, span, req, attempt, resp, err, backoff)
+		discard(resp)
+
+		if err := wait(ctx, backoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends a copy of req bounded by the attempt timeout. The attempt
+// context is released when the response body is closed.
+func (c *Client) attempt(ctx context.Context, req *http.Request, attempt int) (*http.Response, error) {
+	var (
+		attemptCtx context.Context
+		cancel     context.CancelFunc
+	)
+	if c.retry.AttemptTimeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, c.retry.AttemptTimeout)
+	} else {
+		attemptCtx, cancel = context.WithCancel(ctx)
+	}
+
+	outReq := req.Clone(attemptCtx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		outReq.Body = body
+	}
+	c.propagator.Inject(ctx, propagation.HeaderCarrier(outReq.Header))
+
+	resp, err := c.client.Do(outReq)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
+	return resp, nil
+}
+
+func (c *Client) recordAttempt(
+	ctx context.Context,
+	span trace.Span,
+	req *http.Request,
+	attempt int,
+	resp *http.Response,
+	err error,
+	backoff time.Duration,
+) {
+	status := 0
+	if resp != nil {
+		status = resp.StatusCode
+	}
+
+	attr := []attribute.KeyValue{
+		attribute.Int("attempt", attempt),
+		attribute.Int("status", status),
+	}
+	if err != nil {
+		attr = append(attr, attribute.String("error", err.Error()))
+	}
+	if backoff > 0 {
+		attr = append(attr, attribute.Int64("backoff_ms", backoff.Milliseconds()))
+	}
+	span.AddEvent("http.client.attempt", trace.WithAttributes(attr...))
+
+	c.attempts.Add(ctx, 1, metric.WithAttributes(
+		attribute.Bool("retry", attempt > 1),
+		attribute.String("status_class", statusClass(status)),
+		attribute.String("method", req.Method),
+		attribute.String("host", req.URL.Hostname()),
+		attribute.String("client", c.name),
+	))
+}
+
+func (c *Client) recordRequest(ctx context.Context, status int, delta time.Time, req *http.Request) {
+	attr := metric.WithAttributes(
+		attribute.Int("status", status),
+		attribute.String("status_class", statusClass(status)),
+		attribute.String("method", req.Method),
+		attribute.String("host", req.URL.Hostname()),
+		attribute.String("client", c.name),
+	)
+
+	c.counter.Add(ctx, 1, attr)
+
+	// Use floating point division here for higher precision (instead of Millisecond method).
+	elapsedTime := float64(time.Since(delta)) / float64(time.Millisecond)
+	c.duration.Record(ctx, elapsedTime, attr)
+}
+
+// statusClass groups the given status code in 2xx, 3xx, 4xx or 5xx. A zero
+// status code, which stands for a transport error, is reported as error.
+func statusClass(status int) string {
+	if status <= 0 {
+		return "error"
+	}
+
+	return strconv.Itoa(status/100) + "xx"
+}
+
+func newTransport(name string, opts ClientOptions) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   opts.DialTimeout,
+		KeepAlive: _defaultKeepAlive,
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = trackConns(name, dialer.DialContext)
+	t.MaxIdleConns = opts.MaxIdleConns
+	t.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	t.IdleConnTimeout = opts.IdleConnTimeout
+
+	return t
+}
+
+func validateName(name string) error {
+	if name == "" {
+		return ErrInvalidClientName
+	}
+
+	whitespace := regexp.MustCompile(`\s`)
+	if whitespace.MatchString(name) {
+		return ErrInvalidClientName
+	}
+
+	return nil
+}
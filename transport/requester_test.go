@@ -0,0 +1,193 @@
+package transport_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/baggage"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/pomelo-la/go-toolkit/transport"
+)
+
+func TestNewHTTPClient(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "valid name", input: "cards-api"},
+		{name: "empty name", input: "", wantErr: transport.ErrInvalidClientName},
+		{name: "name with blank spaces", input: "cards api", wantErr: transport.ErrInvalidClientName},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := transport.NewHTTPClient(tc.input)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				assert.Nil(t, client)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, client)
+		})
+	}
+}
+
+func TestClientDo_PropagatesContext(t *testing.T) {
+	var gotHeader http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client, err := transport.NewHTTPClient("propagation", transport.WithTracerProvider(tp))
+	require.NoError(t, err)
+
+	member, err := baggage.NewMember("owner", "example")
+	require.NoError(t, err)
+	bag, err := baggage.New(member)
+	require.NoError(t, err)
+
+	ctx, parent := tp.Tracer("test").Start(baggage.ContextWithBaggage(context.Background(), bag), "parent")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	parent.End()
+
+	assert.Empty(t, req.Header.Get("traceparent"), "the original request must not be modified")
+	assert.Contains(t, gotHeader.Get("traceparent"), parent.SpanContext().TraceID().String())
+	assert.Equal(t, "owner=example", gotHeader.Get("baggage"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "HTTP GET", spans[0].Name())
+	assert.Equal(t, trace.SpanKindClient, spans[0].SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
+
+func TestClientDo_RecordsMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer srv.Close()
+
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	client, err := transport.NewHTTPClient("metrics", transport.WithMeterProvider(mp))
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	names := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		names[m.Name] = m.Data
+	}
+	require.Contains(t, names, "http.client.request.counter")
+	require.Contains(t, names, "http.client.request.duration")
+
+	sum := names["http.client.request.counter"].(metricdata.Sum[int64])
+	require.Len(t, sum.DataPoints, 1)
+	assert.EqualValues(t, 1, sum.DataPoints[0].Value)
+
+	statusClass, ok := sum.DataPoints[0].Attributes.Value("status_class")
+	assert.True(t, ok)
+	assert.Equal(t, "4xx", statusClass.AsString())
+}
+
+type breaker struct{ mock.Mock }
+
+func (b *breaker) Allow() bool {
+	args := b.Called()
+	return args.Bool(0)
+}
+func (b *breaker) Success() { b.Called() }
+func (b *breaker) Failure() { b.Called() }
+
+func TestClientDo_CircuitBreaker(t *testing.T) {
+	tests := []struct {
+		name          string
+		handlerStatus int
+		setupMock     func(b *breaker)
+		wantErr       error
+	}{
+		{
+			name:          "success on response <500",
+			handlerStatus: http.StatusNotFound,
+			setupMock: func(b *breaker) {
+				b.On("Allow").Return(true).Once()
+				b.On("Success").Once()
+			},
+		},
+		{
+			name:          "failure on response >=500",
+			handlerStatus: http.StatusInternalServerError,
+			setupMock: func(b *breaker) {
+				b.On("Allow").Return(true).Once()
+				b.On("Failure").Once()
+			},
+		},
+		{
+			name:          "rejected on open circuit",
+			handlerStatus: http.StatusOK,
+			setupMock: func(b *breaker) {
+				b.On("Allow").Return(false).Once()
+			},
+			wantErr: transport.ErrCircuitOpen,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.handlerStatus)
+			}))
+			defer srv.Close()
+
+			var cb breaker
+			defer cb.AssertExpectations(t)
+			tc.setupMock(&cb)
+
+			client, err := transport.NewHTTPClient("breaker", transport.WithCircuitBreaker(&cb))
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tc.handlerStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	_defaultMaxAttempts     = 3
+	_defaultInitialInterval = 100 * time.Millisecond
+	_defaultMaxInterval     = 2 * time.Second
+	_defaultMultiplier      = 2
+	_defaultJitter          = 0.5
+)
+
+// RetryOptions represents the retry policy applied by a Client.
+type RetryOptions struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts int
+	// InitialInterval is the backoff applied after the first attempt.
+	InitialInterval time.Duration
+	// MaxInterval caps the backoff between attempts, including the one asked
+	// by the Retry-After header of the response.
+	MaxInterval time.Duration
+	// Multiplier is the factor by which the backoff grows after every attempt.
+	Multiplier float64
+	// Jitter randomizes the backoff within [backoff*(1-Jitter), backoff*(1+Jitter)].
+	Jitter float64
+	// MaxElapsedTime is the maximum time spent retrying a request, zero means
+	// that only MaxAttempts limits the retries.
+	MaxElapsedTime time.Duration
+	// AttemptTimeout is the time limit of every single attempt, zero means
+	// that only the client timeout applies.
+	AttemptTimeout time.Duration
+	// StatusCodes holds the response status codes that are retried.
+	StatusCodes []int
+	// Methods holds the HTTP methods that are retried.
+	Methods []string
+}
+
+// WithRetry allows you to configure the retry policy of the client.
+//
+// Default behavior is to not retry requests. When this option is used
+// requests are attempted up to 3 times using an exponential backoff with
+// jitter, retrying idempotent methods on connection errors and on 429, 502,
+// 503 and 504 responses.
+func WithRetry(optFns ...func(opts *RetryOptions)) func(opts *ClientOptions) {
+	return func(opts *ClientOptions) {
+		retry := RetryOptions{
+			MaxAttempts:     _defaultMaxAttempts,
+			InitialInterval: _defaultInitialInterval,
+			MaxInterval:     _defaultMaxInterval,
+			Multiplier:      _defaultMultiplier,
+			Jitter:          _defaultJitter,
+			StatusCodes: []int{
+				http.StatusTooManyRequests,
+				http.StatusBadGateway,
+				http.StatusServiceUnavailable,
+				http.StatusGatewayTimeout,
+			},
+			Methods: []string{
+				http.MethodGet,
+				http.MethodHead,
+				http.MethodOptions,
+				http.MethodTrace,
+				http.MethodPut,
+				http.MethodDelete,
+			},
+		}
+		for _, fn := range optFns {
+			fn(&retry)
+		}
+
+		opts.Retry = retry
+	}
+}
+
+// WithMaxAttempts allows you to configure the maximum number of attempts,
+// including the first one.
+func WithMaxAttempts(attempts int) func(opts *RetryOptions) {
+	return func(opts *RetryOptions) {
+		opts.MaxAttempts = attempts
+	}
+}
+
+// WithBackoff allows you to configure the exponential backoff between
+// attempts.
+func WithBackoff(initial, maxInterval time.Duration, multiplier float64) func(opts *RetryOptions) {
+	return func(opts *RetryOptions) {
+		opts.InitialInterval = initial
+		opts.MaxInterval = maxInterval
+		opts.Multiplier = multiplier
+	}
+}
+
+// WithJitter allows you to configure the randomization factor applied to the
+// backoff, a value of zero disables the jitter.
+func WithJitter(jitter float64) func(opts *RetryOptions) {
+	return func(opts *RetryOptions) {
+		opts.Jitter = jitter
+	}
+}
+
+// WithMaxElapsedTime allows you to configure the maximum time spent retrying
+// a request. No retry is attempted if its backoff would exceed that time.
+func WithMaxElapsedTime(maxElapsed time.Duration) func(opts *RetryOptions) {
+	return func(opts *RetryOptions) {
+		opts.MaxElapsedTime = maxElapsed
+	}
+}
+
+// WithAttemptTimeout allows you to configure the time limit of every single
+// attempt.
+func WithAttemptTimeout(timeout time.Duration) func(opts *RetryOptions) {
+	return func(opts *RetryOptions) {
+		opts.AttemptTimeout = timeout
+	}
+}
+
+// WithRetryStatusCodes allows you to configure the response status codes that
+// are retried.
+func WithRetryStatusCodes(codes ...int) func(opts *RetryOptions) {
+	return func(opts *RetryOptions) {
+		opts.StatusCodes = codes
+	}
+}
+
+// WithRetryMethods allows you to configure the HTTP methods that are retried.
+//
+// Only add non-idempotent methods such as POST when the server is able to
+// deduplicate requests, e.g. through an idempotency key.
+func WithRetryMethods(methods ...string) func(opts *RetryOptions) {
+	return func(opts *RetryOptions) {
+		opts.Methods = methods
+	}
+}
+
+// canRetry reports whether the request may be sent more than once.
+// Requests with a body are only retried if it can be obtained again.
+func (o RetryOptions) canRetry(req *http.Request) bool {
+	if o.MaxAttempts <= 1 || !slices.Contains(o.Methods, req.Method) {
+		return false
+	}
+
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// shouldRetry reports whether the outcome of an attempt must be retried.
+func (o RetryOptions) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+
+	return slices.Contains(o.StatusCodes, resp.StatusCode)
+}
+
+// backoff returns the time to wait before the next attempt. The Retry-After
+// header of the response, when present, takes precedence, still capped by
+// MaxInterval.
+func (o RetryOptions) backoff(attempt int, resp *http.Response) time.Duration {
+	if d, ok := retryAfter(resp); ok {
+		if o.MaxInterval > 0 && d > o.MaxInterval {
+			return o.MaxInterval
+		}
+		return d
+	}
+
+	interval := float64(o.InitialInterval)
+	for i := 1; i < attempt; i++ {
+		interval *= o.Multiplier
+	}
+
+	if o.MaxInterval > 0 && interval > float64(o.MaxInterval) {
+		interval = float64(o.MaxInterval)
+	}
+
+	if o.Jitter > 0 {
+		delta := o.Jitter * interval
+		interval = interval - delta + rand.Float64()*(2*delta)
+	}
+
+	return time.Duration(interval)
+}
+
+// exhausted reports whether waiting backoff before the next attempt would
+// exceed MaxElapsedTime or the deadline of the context, in which case there
+// is no point in retrying.
+func (o RetryOptions) exhausted(ctx context.Context, start time.Time, backoff time.Duration) bool {
+	if o.MaxElapsedTime > 0 && time.Since(start)+backoff > o.MaxElapsedTime {
+		return true
+	}
+
+	deadline, ok := ctx.Deadline()
+	return ok && time.Until(deadline) < backoff
+}
+
+// retryAfter parses the Retry-After header, either in delay-seconds or in
+// HTTP-date format.
+//
+// RFC for more info https://www.rfc-editor.org/rfc/rfc9110#field.retry-after
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
+
+// wait blocks for the given duration or until the context is done.
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// discard drains and closes the body of a response that is not going to be
+// returned, so that its connection can be reused.
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// cancelBody releases the attempt context once the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
@@ -0,0 +1,198 @@
+package transport_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/pomelo-la/go-toolkit/transport"
+)
+
+func TestClientDo_Retry(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		statuses     []int
+		retryAfter   string
+		optFns       []func(opts *transport.RetryOptions)
+		timeout      time.Duration
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{
+			name:         "retries until success",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			wantStatus:   http.StatusOK,
+			wantAttempts: 3,
+		},
+		{
+			name:         "returns last response when attempts are exhausted",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusServiceUnavailable},
+			optFns:       []func(opts *transport.RetryOptions){transport.WithMaxAttempts(2)},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 2,
+		},
+		{
+			name:         "does not retry non retryable status codes",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusInternalServerError},
+			wantStatus:   http.StatusInternalServerError,
+			wantAttempts: 1,
+		},
+		{
+			name:         "does not retry non idempotent methods",
+			method:       http.MethodPost,
+			body:         `{"id":1}`,
+			statuses:     []int{http.StatusServiceUnavailable},
+			wantStatus:   http.StatusServiceUnavailable,
+			wantAttempts: 1,
+		},
+		{
+			name:     "retries configured methods resending the body",
+			method:   http.MethodPost,
+			body:     `{"id":1}`,
+			statuses: []int{http.StatusServiceUnavailable, http.StatusCreated},
+			optFns: []func(opts *transport.RetryOptions){
+				transport.WithRetryMethods(http.MethodPost),
+			},
+			wantStatus:   http.StatusCreated,
+			wantAttempts: 2,
+		},
+		{
+			name:         "honors retry after within max elapsed time",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "0",
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+		{
+			name:       "stops when retry after exceeds max elapsed time",
+			method:     http.MethodGet,
+			statuses:   []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter: "120",
+			optFns: []func(opts *transport.RetryOptions){
+				transport.WithBackoff(time.Millisecond, time.Hour, 2),
+				transport.WithMaxElapsedTime(time.Second),
+			},
+			wantStatus:   http.StatusTooManyRequests,
+			wantAttempts: 1,
+		},
+		{
+			name:         "caps retry after to max interval",
+			method:       http.MethodGet,
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "120",
+			wantStatus:   http.StatusOK,
+			wantAttempts: 2,
+		},
+		{
+			name:       "stops when retry after exceeds the context deadline",
+			method:     http.MethodGet,
+			statuses:   []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter: "120",
+			optFns: []func(opts *transport.RetryOptions){
+				transport.WithBackoff(time.Millisecond, time.Hour, 2),
+			},
+			timeout:      time.Second,
+			wantStatus:   http.StatusTooManyRequests,
+			wantAttempts: 1,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1))
+				b, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.body, string(b))
+
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.statuses[min(n, len(tc.statuses))-1])
+			}))
+			defer srv.Close()
+
+			optFns := append([]func(opts *transport.RetryOptions){
+				transport.WithBackoff(time.Millisecond, 5*time.Millisecond, 2),
+			}, tc.optFns...)
+			client, err := transport.NewHTTPClient("retry", transport.WithRetry(optFns...))
+			require.NoError(t, err)
+
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			req, err := http.NewRequestWithContext(ctx, tc.method, srv.URL, strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.wantStatus, resp.StatusCode)
+			assert.Equal(t, tc.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestClientDo_RetryAttemptTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	client, err := transport.NewHTTPClient("attempt-timeout",
+		transport.WithTracerProvider(tp),
+		transport.WithRetry(
+			transport.WithAttemptTimeout(50*time.Millisecond),
+			transport.WithBackoff(time.Millisecond, time.Millisecond, 1),
+		))
+	require.NoError(t, err)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, attempts.Load())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	events := spans[0].Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "http.client.attempt", events[0].Name)
+	assert.Equal(t, "http.client.attempt", events[1].Name)
+}
@@ -66,6 +66,8 @@ type AppOptions struct {
 	Environment    string
 	ErrorHandler   httprouter.ErrorHandlerFunc
 	Middlewares    []func(http.Handler) http.Handler
+	OpenAPI        *httprouter.OpenAPIConfig
+	Versioning     *httprouter.VersioningConfig
 }
 
 // WithTimeouts allows you to configure the different timeouts
@@ -129,6 +131,27 @@ func WithGlobalMiddlewares(middlewares ...func(handler http.Handler) http.Handle
 	}
 }
 
+// WithOpenAPI allows you to configure the router to serve the OpenAPI document
+// of its routes, and optionally a documentation UI, as httprouter.WithOpenAPI does.
+// The title of the document is the name of the application when empty.
+//
+// Default behavior is to not serve any OpenAPI document.
+func WithOpenAPI(config httprouter.OpenAPIConfig) func(options *AppOptions) {
+	return func(opts *AppOptions) {
+		opts.OpenAPI = &config
+	}
+}
+
+// WithVersioning allows you to configure the router to select the version of
+// the requests whose path has no version prefix, as httprouter.WithVersioning does.
+//
+// Default behavior is to select versions by their path prefix only.
+func WithVersioning(config httprouter.VersioningConfig) func(options *AppOptions) {
+	return func(opts *AppOptions) {
+		opts.Versioning = &config
+	}
+}
+
 // Run starts your Application, it blocks until os.Interrupt is received.
 func (a *Application) Run() error {
 	ctx := context.Background()
@@ -187,13 +210,15 @@ func (a *Application) configureListener() error {
 	return nil
 }
 
-// printRoutes prints every route grouped by URL and http methods.
+// printRoutes prints every route grouped by URL and http methods, along with
+// the version of the versioned routes.
 // Example:
 //
 // /path                  [GET POST]
 // /path/sub-path         [GET]
 // /path/{id}             [POST]
-// /ping                  [GET].
+// /ping                  [GET]
+// /v2/cards/{id}         [GET]      v2.
 func (a *Application) printRoutes() error {
 	var w tabwriter.Writer
 	w.Init(os.Stdout, 0, 0, 0, ' ', tabwriter.TabIndent)
@@ -204,10 +229,12 @@ func (a *Application) printRoutes() error {
 	}
 
 	m := make(map[string][]string)
+	versions := make(map[string]string)
 	var r []string
 	for _, route := range routes {
 		r = append(r, route.Route)
 		m[route.Route] = append(m[route.Route], route.Method)
+		versions[route.Route] = route.Version
 	}
 
 	visited := make(map[string]struct{})
@@ -216,7 +243,7 @@ func (a *Application) printRoutes() error {
 	for _, v := range r {
 		if _, ok := visited[v]; !ok {
 			sort.Strings(m[v])
-			fmt.Fprintf(&w, "%s\t%v\t\n", v, m[v])
+			fmt.Fprintf(&w, "%s\t%v\t%s\n", v, m[v], versions[v])
 			visited[v] = struct{}{}
 		}
 	}
@@ -245,6 +272,10 @@ func New(serviceName string, optFns ...func(opts *AppOptions)) (*Application, er
 		return nil, err
 	}
 
+	if config.OpenAPI != nil && config.OpenAPI.Info.Title == "" {
+		config.OpenAPI.Info.Title = serviceName
+	}
+
 	if config.ServerTimeouts == (httprouter.Timeouts{}) {
 		config.ServerTimeouts = httprouter.Timeouts{
 			ShutdownTimeout: 5 * time.Second,
@@ -260,7 +291,7 @@ func New(serviceName string, optFns ...func(opts *AppOptions)) (*Application, er
 		if err != nil {
 			return nil, err
 		}
-		router := defaultHTTPRouter(*log, config.ErrorHandler, config.Middlewares...)
+		router := defaultHTTPRouter(*log, config)
 
 		return &Application{
 			config:      config,
@@ -272,7 +303,7 @@ func New(serviceName string, optFns ...func(opts *AppOptions)) (*Application, er
 		}, nil
 	}
 
-	router := defaultHTTPRouter(*log, config.ErrorHandler, config.Middlewares...)
+	router := defaultHTTPRouter(*log, config)
 	return &Application{
 		config:      config,
 		Router:      router,
@@ -347,12 +378,8 @@ func configEnvironment(opt AppOptions) (*Environment, error) {
 	return &environment, nil
 }
 
-func defaultHTTPRouter(
-	log logger.Logger,
-	errorHandlerFunc httprouter.ErrorHandlerFunc,
-	middlewares ...func(handler http.Handler) http.Handler,
-) *httprouter.Router {
-	middlewares = append(middlewares, []func(http.Handler) http.Handler{
+func defaultHTTPRouter(log logger.Logger, config AppOptions) *httprouter.Router {
+	middlewares := append(config.Middlewares, []func(http.Handler) http.Handler{
 		telemetryMiddleware,
 		logMiddleware(log),
 		panicsMiddleware(log),
@@ -375,14 +402,23 @@ func defaultHTTPRouter(
 	})
 	//revive:enable:unused-parameter
 
-	return httprouter.New(
+	optFns := []func(*httprouter.Config){
 		httprouter.WithGlobalMiddlewares(middlewares...),
 		httprouter.WithNotFoundHandler(notFoundHandler),
 		httprouter.WithHealthCheckLivenessHandler(livenessHandler),
 		httprouter.WithHealthCheckReadinessHandler(readinessHandler),
 		httprouter.WithEnableProfiler(true),
-		httprouter.WithErrorHandlerFunc(errorHandlerFunc),
-	)
+		httprouter.WithErrorHandlerFunc(config.ErrorHandler),
+		httprouter.WithLogger(&log),
+	}
+	if config.OpenAPI != nil {
+		optFns = append(optFns, httprouter.WithOpenAPI(*config.OpenAPI))
+	}
+	if config.Versioning != nil {
+		optFns = append(optFns, httprouter.WithVersioning(*config.Versioning))
+	}
+
+	return httprouter.New(optFns...)
 }
 
 // headerForwarder decorates a request context with the value of certain headers
@@ -499,7 +535,14 @@ func telemetryMiddleware(next http.Handler) http.Handler {
 
 		start := time.Now()
 		next.ServeHTTP(w2, r2)
-		recordRequest(r2.Context(), w2.Status(), start, r.Method, routePattern)
+
+		status := w2.Status()
+		if status == 0 && r.Header.Get("Upgrade") != "" {
+			// The connection was hijacked by the upgrade, like the ones of
+			// httprouter.WebSocket, so no status was written through w2.
+			status = http.StatusSwitchingProtocols
+		}
+		recordRequest(r2.Context(), status, start, r.Method, routePattern)
 	})
 }
 
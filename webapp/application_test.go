@@ -1,12 +1,17 @@
 package webapp_test
 
 import (
+	"context"
+	"encoding/json"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/pomelo-la/go-toolkit/httprouter"
 	"github.com/pomelo-la/go-toolkit/logger"
 	"github.com/stretchr/testify/assert"
@@ -175,3 +180,86 @@ func TestApplicationRunError(t *testing.T) {
 		})
 	}
 }
+
+func TestApplicationWebSocket(t *testing.T) {
+	app, err := webapp.New("test-app")
+	require.NoError(t, err)
+
+	app.Router.Get("/echo", httprouter.WebSocket(func(ctx context.Context, conn *websocket.Conn) error {
+		typ, msg, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		return conn.WriteMessage(typ, msg)
+	}))
+
+	// The server does not track hijacked connections, so the test waits for
+	// the handler to return before the application is torn down.
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer close(done)
+		app.Router.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	// The upgrade goes through the telemetry, log and compression middlewares.
+	header := http.Header{"Accept-Encoding": {"gzip"}}
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/echo", header)
+	require.NoError(t, err)
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(msg))
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure), err)
+
+	require.NoError(t, conn.Close())
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("websocket handler did not return")
+	}
+}
+
+func TestApplicationOpenAPI(t *testing.T) {
+	app, err := webapp.New("test-app", webapp.WithOpenAPI(httprouter.OpenAPIConfig{Path: "/spec.json"}))
+	require.NoError(t, err)
+
+	app.Router.Get("/cards/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}, httprouter.WithSummary("Get a card"))
+
+	req := httptest.NewRequest(http.MethodGet, "/spec.json", nil)
+	w := httptest.NewRecorder()
+	app.Router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var doc httprouter.OpenAPI
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&doc))
+
+	assert.Equal(t, "test-app", doc.Info.Title)
+	assert.Equal(t, "Get a card", doc.Paths["/cards/{id}"]["get"].Summary)
+	assert.NotContains(t, doc.Paths, "/liveness")
+}
+
+func TestApplicationVersioning(t *testing.T) {
+	app, err := webapp.New("test-app", webapp.WithVersioning(httprouter.VersioningConfig{Header: "X-API-Version"}))
+	require.NoError(t, err)
+
+	app.Router.Version("v2", func(r httprouter.Router) {
+		r.Get("/cards", func(w http.ResponseWriter, r *http.Request) error {
+			return httprouter.RespondJSON(w, http.StatusOK, "v2")
+		})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/cards", nil)
+	req.Header.Set("X-API-Version", "2")
+	w := httptest.NewRecorder()
+	app.Router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"v2"`, w.Body.String())
+}
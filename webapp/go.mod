@@ -4,6 +4,7 @@ go 1.22
 
 require (
 	github.com/go-chi/chi/v5 v5.0.12
+	github.com/gorilla/websocket v1.5.1
 	github.com/pomelo-la/go-toolkit/httprouter v0.3.3
 	github.com/pomelo-la/go-toolkit/logger v0.1.4
 	github.com/pomelo-la/go-toolkit/telemetry v0.2.2